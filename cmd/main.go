@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"os"
 
 	"github.com/nim-sam/gitport/pkg/server"
@@ -25,8 +26,47 @@ func main() {
 			return
 		}
 		server.Init()
+	case "bundle":
+		runBundle(args[2:])
+	case "hook":
+		// Invoked by git from the hooks installed in the bare repo
+		if len(args) != 3 {
+			println("gitport hook expects a hook name")
+			os.Exit(1)
+		}
+		os.Exit(server.RunHook(args[2]))
 	case "help":
 		println("Help coming soon")
 	}
 
 }
+
+/**
+ * Handles `gitport bundle export|import` for offline transfers
+ */
+func runBundle(args []string) {
+	usage := "Usage:\n\n\tgitport bundle export [--since <ref|date>] [-o <file>]\n\tgitport bundle import <file>"
+	if len(args) < 1 {
+		println(usage)
+		return
+	}
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("bundle export", flag.ContinueOnError)
+		since := fs.String("since", "", "only include history after this ref or date")
+		out := fs.String("o", "", "output bundle file")
+		if err := fs.Parse(args[1:]); err != nil {
+			return
+		}
+		server.BundleExport(*since, *out)
+	case "import":
+		if len(args) != 2 {
+			println(usage)
+			return
+		}
+		server.BundleImport(args[1])
+	default:
+		println(usage)
+	}
+}
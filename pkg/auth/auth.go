@@ -6,12 +6,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/charmbracelet/ssh"
 
 	"github.com/nim-sam/gitport/pkg/logger"
+	"github.com/nim-sam/gitport/pkg/metrics"
 )
 
 type User struct {
@@ -24,6 +26,37 @@ var (
 	dataMu sync.RWMutex
 )
 
+// User change actions
+const (
+	UserEnrolled    = "enrolled" // A guest connected to a public server
+	UserAdded       = "added"
+	UserRemoved     = "removed"
+	UserPermChanged = "perm"
+)
+
+// UserChange describes a user being added, removed or given a new permission
+type UserChange struct {
+	Action  string
+	Key     string
+	Name    string
+	Perm    string
+	OldPerm string
+}
+
+var onUserChanged func(UserChange)
+
+// SetUserChangeCallback sets the function told about every user change
+func SetUserChangeCallback(callback func(UserChange)) {
+	onUserChanged = callback
+}
+
+// notifyUserChange passes a change on to the callback, if any
+func notifyUserChange(change UserChange) {
+	if onUserChanged != nil {
+		onUserChanged(change)
+	}
+}
+
 func InitUsers() error {
 	file, err := os.Open(filepath.Join(logger.ConfigDir, logger.Users))
 	if err != nil {
@@ -58,10 +91,51 @@ func InitUsers() error {
 	return nil
 }
 
-// ReloadUsers reloads user data from disk (called when file changes)
+// ReloadUsers reloads user data from disk (called when file changes). Users
+// added, removed or given a new permission by editing the file are recorded
+// like changes made from the TUI.
 func ReloadUsers() error {
 	logger.Logger.Info("Detected external change, reloading users", "file", logger.Users)
-	return InitUsers()
+
+	dataMu.RLock()
+	old := Data
+	dataMu.RUnlock()
+
+	if err := InitUsers(); err != nil {
+		return err
+	}
+
+	dataMu.RLock()
+	changes := diffUsers(old, Data)
+	dataMu.RUnlock()
+
+	for _, change := range changes {
+		logger.Logger.Info("User changed in users file", "action", change.Action, "name", change.Name, "perm", change.Perm)
+		notifyUserChange(change)
+	}
+	return nil
+}
+
+// diffUsers lists the changes that turn old into new, by key
+func diffUsers(old, new map[string]User) []UserChange {
+	var changes []UserChange
+	for key, user := range new {
+		before, existed := old[key]
+		switch {
+		case !existed:
+			changes = append(changes, UserChange{Action: UserAdded, Key: key, Name: user.Name, Perm: user.Perm})
+		case before.Perm != user.Perm:
+			changes = append(changes, UserChange{Action: UserPermChanged, Key: key, Name: user.Name, Perm: user.Perm, OldPerm: before.Perm})
+		}
+	}
+	for key, user := range old {
+		if _, exists := new[key]; !exists {
+			changes = append(changes, UserChange{Action: UserRemoved, Key: key, Name: user.Name, Perm: user.Perm})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
 }
 
 // GetUserByKey safely retrieves a user by their key string with read lock
@@ -152,6 +226,7 @@ func EnsureHostAdmin() error {
 		return err
 	}
 
+	notifyUserChange(UserChange{Action: UserAdded, Key: normalizedKey, Name: "host (admin)", Perm: "admin"})
 	logger.Logger.Info("Host added as admin", "key_file", keyPath)
 	return nil
 }
@@ -159,7 +234,7 @@ func EnsureHostAdmin() error {
 func GetUser(key ssh.PublicKey) string {
 	userKey := key.Type() + " " + base64.StdEncoding.EncodeToString(key.Marshal())
 
-	user, exist := Data[userKey]
+	user, exist := GetUserByKey(userKey)
 	if !exist {
 		return "guest"
 	}
@@ -170,22 +245,30 @@ func GetUser(key ssh.PublicKey) string {
 func AuthHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 	userKey := key.Type() + " " + base64.StdEncoding.EncodeToString(key.Marshal())
 
-	user, exist := Data[userKey]
+	remote := ctx.RemoteAddr().String()
+
+	user, exist := GetUserByKey(userKey)
 	if !exist {
-		username := ctx.User() + "@" + ctx.RemoteAddr().String()
+		username := ctx.User() + "@" + remote
 
 		if !logger.GetConfigPublic() {
-			logger.Logger.Warn("Unauthorized user tried to connect", "key", username)
+			logger.Logger.Warn("Unauthorized user tried to connect", "user", ctx.User(), "remote", remote)
+			metrics.AuthAttempts.Inc("failure")
 			return false
 		}
 
-		logger.Logger.Info("New user connecting", "user", username)
+		logger.Logger.Info("New user connecting", "user", username, "remote", remote)
 
 		perms := logger.GetConfigDefaultPerm()
 		if perms == "" {
 			logger.Logger.Error("No default permissions in file", "file", logger.Conf)
 			perms = "none"
 		}
+		// auditor grants access to the server logs, so it is only given to users explicitly
+		if perms == "auditor" {
+			logger.Logger.Warn("auditor can't be the default permission, using read", "file", logger.Conf)
+			perms = "read"
+		}
 
 		dataMu.Lock()
 		Data[userKey] = User{
@@ -196,12 +279,15 @@ func AuthHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 
 		if err := SaveUsers(); err != nil {
 			logger.Logger.Error("Could not edit users file", "error", err)
+			metrics.AuthAttempts.Inc("failure")
 			return false
 		}
+		notifyUserChange(UserChange{Action: UserEnrolled, Key: userKey, Name: username, Perm: perms})
 	} else {
-		logger.Logger.Info("User authenticated", "user", user.Name, "perm", user.Perm)
+		logger.Logger.Info("User authenticated", "user", user.Name, "perm", user.Perm, "remote", remote)
 	}
 
+	metrics.AuthAttempts.Inc("success")
 	return true
 }
 
@@ -231,7 +317,11 @@ func UpdateUserPerm(key, perm string) error {
 	Data[key] = user
 	dataMu.Unlock()
 	logger.Logger.Info("User permission updated", "user", user.Name, "old", oldPerm, "new", perm)
-	return SaveUsers()
+	if err := SaveUsers(); err != nil {
+		return err
+	}
+	notifyUserChange(UserChange{Action: UserPermChanged, Key: key, Name: user.Name, Perm: perm, OldPerm: oldPerm})
+	return nil
 }
 
 // AddUser adds a new user and saves to disk
@@ -243,7 +333,11 @@ func AddUser(key, name, perm string) error {
 	}
 	dataMu.Unlock()
 	logger.Logger.Info("User added", "name", name, "perm", perm)
-	return SaveUsers()
+	if err := SaveUsers(); err != nil {
+		return err
+	}
+	notifyUserChange(UserChange{Action: UserAdded, Key: key, Name: name, Perm: perm})
+	return nil
 }
 
 // DeleteUser removes a user and saves to disk
@@ -255,5 +349,11 @@ func DeleteUser(key string) error {
 	}
 	delete(Data, key)
 	dataMu.Unlock()
-	return SaveUsers()
+	if err := SaveUsers(); err != nil {
+		return err
+	}
+	if exists {
+		notifyUserChange(UserChange{Action: UserRemoved, Key: key, Name: user.Name, Perm: user.Perm})
+	}
+	return nil
 }
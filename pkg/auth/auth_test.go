@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffUsers(t *testing.T) {
+	old := map[string]User{
+		"key-a": {Name: "alice", Perm: "admin"},
+		"key-b": {Name: "bob", Perm: "read"},
+		"key-c": {Name: "carol", Perm: "write"},
+	}
+
+	tests := []struct {
+		name string
+		new  map[string]User
+		want []UserChange
+	}{
+		{name: "unchanged", new: old},
+		{
+			name: "renamed only",
+			new: map[string]User{
+				"key-a": {Name: "Alice", Perm: "admin"},
+				"key-b": {Name: "bob", Perm: "read"},
+				"key-c": {Name: "carol", Perm: "write"},
+			},
+		},
+		{
+			name: "added, removed and new permission",
+			new: map[string]User{
+				"key-a": {Name: "alice", Perm: "admin"},
+				"key-b": {Name: "bob", Perm: "write"},
+				"key-d": {Name: "dave", Perm: "read"},
+			},
+			want: []UserChange{
+				{Action: UserPermChanged, Key: "key-b", Name: "bob", Perm: "write", OldPerm: "read"},
+				{Action: UserRemoved, Key: "key-c", Name: "carol", Perm: "write"},
+				{Action: UserAdded, Key: "key-d", Name: "dave", Perm: "read"},
+			},
+		},
+		{
+			name: "emptied",
+			new:  map[string]User{},
+			want: []UserChange{
+				{Action: UserRemoved, Key: "key-a", Name: "alice", Perm: "admin"},
+				{Action: UserRemoved, Key: "key-b", Name: "bob", Perm: "read"},
+				{Action: UserRemoved, Key: "key-c", Name: "carol", Perm: "write"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffUsers(old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffUsers = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
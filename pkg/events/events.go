@@ -0,0 +1,311 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/nim-sam/gitport/pkg/auth"
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// History stores one JSON event per line in the .gitport directory
+const History = "events.jsonl"
+
+// maxHistorySize is the size at which the history is moved to History+".1",
+// replacing the previous one, so at most about twice this is kept on disk
+const maxHistorySize = 4 << 20
+
+// Event types
+const (
+	TypePush  = "push"
+	TypeFetch = "fetch"
+	// TypeUser is a user being enrolled, added, removed or given a new
+	// permission; its Kind is the auth.UserChange action
+	TypeUser = "user"
+	// TypeSession is an SSH session an admin disconnected; its Kind is the
+	// session type
+	TypeSession = "session"
+)
+
+// Fetch kinds
+const (
+	// KindClone requested objects without having any
+	KindClone = "clone"
+	// KindFetch requested objects on top of ones it already had
+	KindFetch = "fetch"
+	// KindRefList only read the refs, as ls-remote and up to date fetches do
+	KindRefList = "ref-list"
+)
+
+// zeroSha is the object name git uses for a missing ref
+const zeroSha = "0000000000000000000000000000000000000000"
+
+// RefUpdate is a single ref changed by a push
+type RefUpdate struct {
+	Ref     string `json:"ref"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Force   bool   `json:"force,omitempty"`
+	Commits int    `json:"commits"`
+	// Summaries are "sha subject" lines of the newest commits pushed
+	Summaries []string `json:"summaries,omitempty"`
+}
+
+// maxSummaries bounds how many commit summaries a ref update keeps
+const maxSummaries = 5
+
+// IsTag reports whether the ref is a tag
+func (u RefUpdate) IsTag() bool { return strings.HasPrefix(u.Ref, "refs/tags/") }
+
+// Name is the ref without its refs/heads/ or refs/tags/ prefix
+func (u RefUpdate) Name() string {
+	return strings.TrimPrefix(strings.TrimPrefix(u.Ref, "refs/heads/"), "refs/tags/")
+}
+
+// Created reports whether the push created the ref
+func (u RefUpdate) Created() bool { return u.Old == zeroSha }
+
+// Deleted reports whether the push deleted the ref
+func (u RefUpdate) Deleted() bool { return u.New == zeroSha }
+
+// Event is a push or fetch made over SSH, a change to the users, or a
+// session an admin disconnected
+type Event struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	Repo        string    `json:"repo"`
+	User        string    `json:"user"`
+	Fingerprint string    `json:"fingerprint"`
+	Remote      string    `json:"remote"`
+
+	// Refs updated by a push
+	Refs []RefUpdate `json:"refs,omitempty"`
+
+	// Kind of fetch, one of the Kind constants, the action of a user change,
+	// or the type of a disconnected session
+	Kind string `json:"kind,omitempty"`
+
+	// By is the admin who disconnected a session
+	By string `json:"by,omitempty"`
+
+	// Perm and OldPerm of a user change
+	Perm    string `json:"perm,omitempty"`
+	OldPerm string `json:"old_perm,omitempty"`
+
+	Duration time.Duration `json:"duration"`
+	BytesIn  int64         `json:"bytes_in"`
+	BytesOut int64         `json:"bytes_out"`
+}
+
+// Commits is the number of new commits across every ref of a push
+func (e Event) Commits() int {
+	total := 0
+	for _, ref := range e.Refs {
+		total += ref.Commits
+	}
+	return total
+}
+
+// Forced reports whether any ref of a push was force-updated
+func (e Event) Forced() bool {
+	for _, ref := range e.Refs {
+		if ref.Force {
+			return true
+		}
+	}
+	return false
+}
+
+// historyMu serialises appends to the history file
+var historyMu sync.Mutex
+
+// RecordUserChange adds a user change to the history. It's set as the auth
+// package's user change callback.
+func RecordUserChange(change auth.UserChange) {
+	e := Event{
+		Time:    time.Now(),
+		Type:    TypeUser,
+		Kind:    change.Action,
+		User:    change.Name,
+		Perm:    change.Perm,
+		OldPerm: change.OldPerm,
+	}
+	if key, _, _, _, err := gossh.ParseAuthorizedKey([]byte(change.Key)); err == nil {
+		e.Fingerprint = gossh.FingerprintSHA256(key)
+	}
+
+	// The auth package logs user changes itself
+	saveEvent(e)
+}
+
+// RecordTermination adds a session disconnected by an admin to the history.
+// kind is the session type, as metrics.SessionType names it.
+func RecordTermination(user, fingerprint, remote, kind, by string) {
+	e := Event{
+		Time:        time.Now(),
+		Type:        TypeSession,
+		Kind:        kind,
+		User:        user,
+		Fingerprint: fingerprint,
+		Remote:      remote,
+		By:          by,
+	}
+
+	// The sessions package logs terminations itself
+	saveEvent(e)
+}
+
+// record logs an event and appends it to the history
+func record(e Event) {
+	switch e.Type {
+	case TypePush:
+		logger.Logger.Info("Push", "repo", e.Repo, "user", e.User, "fingerprint", e.Fingerprint, "remote", e.Remote,
+			"refs", refSummary(e.Refs), "commits", e.Commits(), "force", e.Forced())
+	case TypeFetch:
+		logger.Logger.Info("Fetch", "repo", e.Repo, "user", e.User, "fingerprint", e.Fingerprint, "remote", e.Remote,
+			"kind", e.Kind, "duration", e.Duration.Round(time.Millisecond).String())
+	}
+	saveEvent(e)
+}
+
+// saveEvent appends an event to the history and hands it to subscribers
+func saveEvent(e Event) {
+	if err := appendEvent(e); err != nil {
+		logger.Logger.Error("Could not save event", "file", History, "error", err)
+	}
+	publish(e)
+}
+
+// refSummary renders ref updates as "ref old..new" pairs
+func refSummary(refs []RefUpdate) string {
+	parts := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		parts = append(parts, fmt.Sprintf("%s %s..%s", ref.Ref, shortSha(ref.Old), shortSha(ref.New)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// appendEvent writes an event to the end of the history file
+func appendEvent(e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	path := filepath.Join(logger.ConfigDir, History)
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > maxHistorySize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			logger.Logger.Error("Could not rotate event history", "file", History, "error", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Load reads the newest limit events that keep accepts, oldest first. The
+// rotated history is only read when the current one holds too few.
+func Load(limit int, keep func(Event) bool) ([]Event, error) {
+	path := filepath.Join(logger.ConfigDir, History)
+	events, err := readEvents(path, keep)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) < limit {
+		previous, err := readEvents(path+".1", keep)
+		if err != nil {
+			return nil, err
+		}
+		events = append(previous, events...)
+	}
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, nil
+}
+
+// readEvents reads the events of a history file that keep accepts. Lines
+// that can't be parsed are skipped.
+func readEvents(path string, keep func(Event) bool) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil && keep(e) {
+			events = append(events, e)
+		}
+	}
+	return events, scanner.Err()
+}
+
+var (
+	subscribers   = make(map[chan Event]struct{})
+	subscribersMu sync.Mutex
+)
+
+// Subscribe streams every event recorded from now on. The returned function
+// ends the subscription and closes the channel.
+func Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+
+	subscribersMu.Lock()
+	subscribers[ch] = struct{}{}
+	subscribersMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			delete(subscribers, ch)
+			subscribersMu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish hands an event to every subscriber without blocking
+func publish(e Event) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+
+	for ch := range subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
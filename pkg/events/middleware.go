@@ -0,0 +1,203 @@
+package events
+
+import (
+	"encoding/base64"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/nim-sam/gitport/pkg/auth"
+)
+
+// recordingSession watches a git session's traffic and exit status
+type recordingSession struct {
+	ssh.Session
+	sniffer *pktSniffer
+
+	mu       sync.Mutex
+	exitCode int
+	bytesIn  int64
+	bytesOut int64
+}
+
+func (s *recordingSession) Read(p []byte) (int, error) {
+	n, err := s.Session.Read(p)
+	s.mu.Lock()
+	s.bytesIn += int64(n)
+	s.sniffer.feed(p[:n])
+	s.mu.Unlock()
+	return n, err
+}
+
+func (s *recordingSession) Write(p []byte) (int, error) {
+	n, err := s.Session.Write(p)
+	s.mu.Lock()
+	s.bytesOut += int64(n)
+	s.mu.Unlock()
+	return n, err
+}
+
+func (s *recordingSession) Exit(code int) error {
+	s.mu.Lock()
+	s.exitCode = code
+	s.mu.Unlock()
+	return s.Session.Exit(code)
+}
+
+/*
+ * Middleware records a detailed event for every push and fetch. It must
+ * wrap git.Middleware, reading the ref updates and wants the client sends.
+ */
+func Middleware(repoDir string) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			cmd := sess.Command()
+			if len(cmd) != 2 || (cmd[0] != "git-receive-pack" && cmd[0] != "git-upload-pack") {
+				next(sess)
+				return
+			}
+
+			repo := filepath.Clean(strings.TrimSuffix(strings.TrimPrefix(cmd[1], "/"), "/"))
+			repoPath := filepath.Join(repoDir, repo)
+			push := cmd[0] == "git-receive-pack"
+
+			// Refs before the push tell new commits apart from ones already stored
+			var before []string
+			if push {
+				before = refShas(repoPath)
+			}
+
+			rs := &recordingSession{Session: sess, sniffer: &pktSniffer{stopAtFlush: push}}
+			start := time.Now()
+			next(rs)
+
+			rs.mu.Lock()
+			defer rs.mu.Unlock()
+			if rs.exitCode != 0 {
+				return
+			}
+
+			e := Event{
+				Time:     start,
+				Repo:     repo,
+				Remote:   sess.RemoteAddr().String(),
+				Duration: time.Since(start),
+				BytesIn:  rs.bytesIn,
+				BytesOut: rs.bytesOut,
+			}
+			if key := sess.PublicKey(); key != nil {
+				e.Fingerprint = gossh.FingerprintSHA256(key)
+				e.User = sess.User()
+				if user, ok := auth.GetUserByKey(key.Type() + " " + base64.StdEncoding.EncodeToString(key.Marshal())); ok {
+					e.User = user.Name
+				}
+			}
+
+			if push {
+				e.Type = TypePush
+				e.Refs = appliedUpdates(repoPath, rs.sniffer.commands, before)
+				if len(e.Refs) == 0 {
+					// Every update was rejected, the hook already logged why
+					return
+				}
+			} else {
+				e.Type = TypeFetch
+				switch {
+				case rs.sniffer.wants == 0:
+					e.Kind = KindRefList
+				case rs.sniffer.haves == 0:
+					e.Kind = KindClone
+				default:
+					e.Kind = KindFetch
+				}
+			}
+
+			record(e)
+		}
+	}
+}
+
+// appliedUpdates keeps the ref updates the repository accepted, filling in
+// whether each was forced and how many commits it brought
+func appliedUpdates(repoPath string, commands []RefUpdate, before []string) []RefUpdate {
+	current := refMap(repoPath)
+
+	var applied []RefUpdate
+	for _, u := range commands {
+		sha, exists := current[u.Ref]
+		if u.Deleted() {
+			if exists {
+				continue
+			}
+		} else if sha != u.New {
+			continue
+		}
+
+		if !u.Created() && !u.Deleted() {
+			u.Force = !isAncestor(repoPath, u.Old, u.New)
+		}
+		if !u.Deleted() {
+			u.Commits, u.Summaries = newCommits(repoPath, u.New, before)
+		}
+		applied = append(applied, u)
+	}
+	return applied
+}
+
+// refMap lists the refs of a repository by name
+func refMap(repoPath string) map[string]string {
+	out, err := exec.Command("git", "-C", repoPath, "for-each-ref", "--format=%(objectname) %(refname)").Output()
+	refs := make(map[string]string)
+	if err != nil {
+		return refs
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if sha, ref, ok := strings.Cut(line, " "); ok {
+			refs[ref] = sha
+		}
+	}
+	return refs
+}
+
+// refShas lists the object names the refs of a repository point at
+func refShas(repoPath string) []string {
+	var shas []string
+	for _, sha := range refMap(repoPath) {
+		shas = append(shas, sha)
+	}
+	return shas
+}
+
+// isAncestor reports whether from is reachable from to, i.e. a fast-forward
+func isAncestor(repoPath, from, to string) bool {
+	return exec.Command("git", "-C", repoPath, "merge-base", "--is-ancestor", from, to).Run() == nil
+}
+
+// newCommits counts the commits reachable from sha that no ref reached
+// before the push, returning summaries of the newest ones
+func newCommits(repoPath, sha string, before []string) (int, []string) {
+	var input strings.Builder
+	input.WriteString(sha + "\n")
+	for _, b := range before {
+		input.WriteString("^" + b + "\n")
+	}
+
+	cmd := exec.Command("git", "-C", repoPath, "log", "--format=%h %s", "--stdin")
+	cmd.Stdin = strings.NewReader(input.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, nil
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if lines[0] == "" {
+		return 0, nil
+	}
+	return len(lines), lines[:min(len(lines), maxSummaries)]
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// maxSniff bounds how much client input is inspected
+const maxSniff = 1 << 20
+
+// pktSniffer follows the pkt-lines a git client sends, picking out ref
+// update commands for receive-pack and want/have lines for upload-pack
+type pktSniffer struct {
+	// stopAtFlush ends sniffing at the first flush, where a push's pack data starts
+	stopAtFlush bool
+
+	buf  []byte
+	seen int
+	done bool
+
+	commands     []RefUpdate
+	wants, haves int
+}
+
+// feed inspects the next chunk of client input
+func (s *pktSniffer) feed(p []byte) {
+	if s.done {
+		return
+	}
+	s.seen += len(p)
+	if s.seen > maxSniff {
+		s.done = true
+		return
+	}
+	s.buf = append(s.buf, p...)
+
+	for len(s.buf) >= 4 {
+		size, err := strconv.ParseUint(string(s.buf[:4]), 16, 16)
+		if err != nil {
+			s.done = true
+			return
+		}
+
+		// Flush, delimiter and response-end packets carry no payload
+		if size < 4 {
+			s.buf = s.buf[4:]
+			if size == 0 && s.stopAtFlush {
+				s.done = true
+				return
+			}
+			continue
+		}
+
+		if len(s.buf) < int(size) {
+			return
+		}
+		s.line(s.buf[4:size])
+		s.buf = s.buf[size:]
+	}
+}
+
+// line handles a single pkt-line payload
+func (s *pktSniffer) line(payload []byte) {
+	// Capabilities follow a NUL on the first line
+	if i := bytes.IndexByte(payload, 0); i >= 0 {
+		payload = payload[:i]
+	}
+	text := strings.TrimSuffix(string(payload), "\n")
+
+	switch {
+	case strings.HasPrefix(text, "want "):
+		s.wants++
+	case strings.HasPrefix(text, "have "):
+		s.haves++
+	case s.stopAtFlush:
+		fields := strings.Fields(text)
+		if len(fields) == 3 && len(fields[0]) == len(zeroSha) && len(fields[1]) == len(zeroSha) {
+			s.commands = append(s.commands, RefUpdate{Old: fields[0], New: fields[1], Ref: fields[2]})
+		}
+	}
+}
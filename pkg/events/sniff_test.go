@@ -0,0 +1,111 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// pkt frames a payload as a pkt-line
+func pkt(payload string) string {
+	return fmt.Sprintf("%04x%s", len(payload)+4, payload)
+}
+
+func TestPktSniffer(t *testing.T) {
+	a := strings.Repeat("a", 40)
+	b := strings.Repeat("b", 40)
+	c := strings.Repeat("c", 40)
+
+	push := pkt(zeroSha+" "+a+" refs/heads/main\x00report-status side-band-64k\n") +
+		pkt(b+" "+c+" refs/tags/v1\n") +
+		"0000" +
+		"PACK" + pkt(a+" "+b+" refs/heads/after-flush\n")
+	fetch := pkt("want "+a+" multi_ack side-band-64k\n") +
+		pkt("want "+b+"\n") +
+		"0000" +
+		pkt("have "+c+"\n") +
+		pkt("done\n")
+
+	tests := []struct {
+		name        string
+		stopAtFlush bool
+		input       string
+		chunk       int // Size of the reads the input arrives in, zero for a single one
+		commands    []RefUpdate
+		wants       int
+		haves       int
+		done        bool
+	}{
+		{
+			name:        "push",
+			stopAtFlush: true,
+			input:       push,
+			commands: []RefUpdate{
+				{Old: zeroSha, New: a, Ref: "refs/heads/main"},
+				{Old: b, New: c, Ref: "refs/tags/v1"},
+			},
+			done: true,
+		},
+		{
+			name:        "push in single bytes",
+			stopAtFlush: true,
+			input:       push,
+			chunk:       1,
+			commands: []RefUpdate{
+				{Old: zeroSha, New: a, Ref: "refs/heads/main"},
+				{Old: b, New: c, Ref: "refs/tags/v1"},
+			},
+			done: true,
+		},
+		{name: "fetch", input: fetch, wants: 2, haves: 1},
+		{name: "fetch in odd reads", input: fetch, chunk: 7, wants: 2, haves: 1},
+		{name: "protocol v2 delimiter", input: pkt("command=fetch\n") + "0001" + pkt("want "+a+"\n") + "0000", wants: 1},
+		{name: "incomplete line waits for more", input: pkt("want " + a + "\n")[:20]},
+		{name: "malformed length", input: "zzzz" + pkt("want "+a+"\n"), done: true},
+		{name: "update commands aren't read from fetches", input: pkt(zeroSha + " " + a + " refs/heads/main\n")},
+		{name: "malformed update commands are ignored", stopAtFlush: true, input: pkt("abc "+a+" refs/heads/main\n") + pkt(a+" refs/heads/main\n") + "0000", done: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &pktSniffer{stopAtFlush: tt.stopAtFlush}
+			input := []byte(tt.input)
+			chunk := tt.chunk
+			if chunk == 0 {
+				chunk = len(input)
+			}
+			for len(input) > 0 {
+				n := min(chunk, len(input))
+				s.feed(input[:n])
+				input = input[n:]
+			}
+
+			if !reflect.DeepEqual(s.commands, tt.commands) {
+				t.Errorf("commands = %+v, want %+v", s.commands, tt.commands)
+			}
+			if s.wants != tt.wants || s.haves != tt.haves {
+				t.Errorf("wants, haves = %d, %d, want %d, %d", s.wants, s.haves, tt.wants, tt.haves)
+			}
+			if s.done != tt.done {
+				t.Errorf("done = %v, want %v", s.done, tt.done)
+			}
+		})
+	}
+}
+
+func TestPktSnifferLimit(t *testing.T) {
+	s := &pktSniffer{}
+	line := []byte(pkt("have " + strings.Repeat("a", 40) + "\n"))
+	for s.seen <= maxSniff {
+		s.feed(line)
+	}
+	haves := s.haves
+	s.feed(line)
+	if !s.done || s.haves != haves {
+		t.Errorf("sniffer kept reading past %d bytes", maxSniff)
+	}
+	if len(s.buf) > maxSniff {
+		t.Errorf("sniffer buffered %d bytes", len(s.buf))
+	}
+}
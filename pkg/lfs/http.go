@@ -0,0 +1,410 @@
+package lfs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish/git"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+const (
+	mediaType = "application/vnd.git-lfs+json"
+	tokenTTL  = time.Hour
+)
+
+// token grants HTTP access to the LFS API, issued by git-lfs-authenticate
+type token struct {
+	repo    string
+	key     ssh.PublicKey
+	user    string
+	userKey string
+	expires time.Time
+}
+
+var (
+	// Tokens are only held in memory: they all expire when the server
+	// restarts, and clients then authenticate over SSH again
+	tokens   = make(map[string]token)
+	tokensMu sync.Mutex
+	baseURL  string
+)
+
+// issueToken creates a short-lived token for the given user and repo
+func issueToken(repo string, key ssh.PublicKey, user, userKey string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	value := hex.EncodeToString(raw)
+
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	// Drop expired tokens while we hold the lock
+	now := time.Now()
+	for k, t := range tokens {
+		if now.After(t.expires) {
+			delete(tokens, k)
+		}
+	}
+
+	tokens[value] = token{repo: repo, key: key, user: user, userKey: userKey, expires: now.Add(tokenTTL)}
+	return value, nil
+}
+
+// lookupToken returns the token from a request's Authorization header
+func lookupToken(r *http.Request) (token, bool) {
+	value, ok := strings.CutPrefix(r.Header.Get("Authorization"), "RemoteAuth ")
+	if !ok {
+		return token{}, false
+	}
+
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	t, ok := tokens[value]
+	if !ok || time.Now().After(t.expires) {
+		return token{}, false
+	}
+	return t, true
+}
+
+// authResponse is what git-lfs-authenticate prints for the client
+type authResponse struct {
+	Href      string            `json:"href"`
+	Header    map[string]string `json:"header"`
+	ExpiresIn int               `json:"expires_in"`
+}
+
+// StartHTTP serves the LFS HTTP API on addr. publicURL is the address
+// clients are told to use, such as http://192.168.1.10:8081.
+func StartHTTP(addr, publicURL string) *http.Server {
+	baseURL = strings.TrimSuffix(publicURL, "/")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /{repo}/info/lfs/objects/batch", handleBatch)
+	mux.HandleFunc("GET /{repo}/info/lfs/objects/{oid}", handleDownload)
+	mux.HandleFunc("PUT /{repo}/info/lfs/objects/{oid}", handleUpload)
+	mux.HandleFunc("POST /{repo}/info/lfs/objects/verify", handleVerify)
+	mux.HandleFunc("POST /{repo}/info/lfs/locks", handleCreateLock)
+	mux.HandleFunc("GET /{repo}/info/lfs/locks", handleListLocks)
+	mux.HandleFunc("POST /{repo}/info/lfs/locks/verify", handleVerifyLocks)
+	mux.HandleFunc("POST /{repo}/info/lfs/locks/{id}/unlock", handleUnlock)
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Logger.Error("LFS HTTP server stopped", "error", err)
+		}
+	}()
+
+	logger.Logger.Info("LFS HTTP API listening", "addr", addr, "url", baseURL)
+	return server
+}
+
+// authorizeRequest checks the request token and returns it if the holder
+// has at least the wanted access level on the repo in the URL
+func authorizeRequest(w http.ResponseWriter, r *http.Request, want git.AccessLevel) (token, bool) {
+	t, ok := lookupToken(r)
+	if !ok {
+		w.Header().Set("LFS-Authenticate", "RemoteAuth")
+		writeError(w, http.StatusUnauthorized, "missing or expired credentials")
+		return token{}, false
+	}
+
+	repo := r.PathValue("repo")
+	if repo != t.repo || authorize(repo, t.key) < want {
+		writeError(w, http.StatusForbidden, "access denied")
+		return token{}, false
+	}
+	return t, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"message": msg})
+}
+
+type batchObject struct {
+	Oid  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+type batchRequest struct {
+	Operation string        `json:"operation"`
+	Objects   []batchObject `json:"objects"`
+}
+
+type batchAction struct {
+	Href      string            `json:"href"`
+	Header    map[string]string `json:"header,omitempty"`
+	ExpiresIn int               `json:"expires_in,omitempty"`
+}
+
+type batchError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type batchResponseObject struct {
+	Oid           string                 `json:"oid"`
+	Size          int64                  `json:"size"`
+	Authenticated bool                   `json:"authenticated,omitempty"`
+	Actions       map[string]batchAction `json:"actions,omitempty"`
+	Error         *batchError            `json:"error,omitempty"`
+}
+
+// handleBatch tells the client which objects to transfer and where
+func handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid batch request")
+		return
+	}
+
+	want := git.ReadOnlyAccess
+	if req.Operation == "upload" {
+		want = git.ReadWriteAccess
+	}
+	if _, ok := authorizeRequest(w, r, want); !ok {
+		return
+	}
+
+	repo := r.PathValue("repo")
+	header := map[string]string{"Authorization": r.Header.Get("Authorization")}
+	objectsURL := baseURL + "/" + repo + "/info/lfs/objects/"
+
+	var q quota
+	if req.Operation == "upload" {
+		q = loadQuota()
+	}
+
+	var objects []batchResponseObject
+	for _, obj := range req.Objects {
+		res := batchResponseObject{Oid: obj.Oid, Size: obj.Size, Authenticated: true}
+		stored, exists := objectSize(obj.Oid)
+
+		switch {
+		case !validOid(obj.Oid) || obj.Size < 0:
+			res.Error = &batchError{Code: 422, Message: "invalid object"}
+		case req.Operation == "upload":
+			if exists && stored == obj.Size {
+				break
+			}
+			if err := q.admit(obj.Size); err != nil {
+				res.Error = &batchError{Code: 422, Message: err.Error()}
+				break
+			}
+			// The size travels in the href, as chunked uploads carry no Content-Length
+			res.Actions = map[string]batchAction{
+				"upload": {Href: fmt.Sprintf("%s%s?size=%d", objectsURL, obj.Oid, obj.Size), Header: header, ExpiresIn: int(tokenTTL.Seconds())},
+				"verify": {Href: objectsURL + "verify", Header: header, ExpiresIn: int(tokenTTL.Seconds())},
+			}
+		case !exists:
+			res.Error = &batchError{Code: 404, Message: "object not found"}
+		default:
+			res.Actions = map[string]batchAction{
+				"download": {Href: objectsURL + obj.Oid, Header: header, ExpiresIn: int(tokenTTL.Seconds())},
+			}
+		}
+		objects = append(objects, res)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transfer":  "basic",
+		"objects":   objects,
+		"hash_algo": "sha256",
+	})
+}
+
+// handleDownload streams an object to the client
+func handleDownload(w http.ResponseWriter, r *http.Request) {
+	t, ok := authorizeRequest(w, r, git.ReadOnlyAccess)
+	if !ok {
+		return
+	}
+
+	oid := r.PathValue("oid")
+	file, size, err := openObject(oid)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, oid, time.Time{}, file)
+
+	logger.Logger.Info("LFS object downloaded", "oid", oid, "size", size, "user", t.user, "repo", t.repo)
+}
+
+// handleUpload stores an object sent by the client
+func handleUpload(w http.ResponseWriter, r *http.Request) {
+	t, ok := authorizeRequest(w, r, git.ReadWriteAccess)
+	if !ok {
+		return
+	}
+
+	oid := r.PathValue("oid")
+	size := r.ContentLength
+	if s := r.URL.Query().Get("size"); s != "" {
+		var err error
+		if size, err = strconv.ParseInt(s, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid size")
+			return
+		}
+	}
+	if size < 0 {
+		writeError(w, http.StatusLengthRequired, "object size unknown")
+		return
+	}
+
+	if err := putObject(oid, size, r.Body); err != nil {
+		logger.Logger.Warn("LFS upload rejected", "oid", oid, "user", t.user, "error", err)
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, ErrTooLarge) {
+			// Don't read the rest of an oversized body
+			w.Header().Set("Connection", "close")
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+
+	logger.Logger.Info("LFS object uploaded", "oid", oid, "size", size, "user", t.user, "repo", t.repo)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleVerify confirms an uploaded object was stored correctly
+func handleVerify(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authorizeRequest(w, r, git.ReadWriteAccess); !ok {
+		return
+	}
+
+	var obj batchObject
+	if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid verify request")
+		return
+	}
+
+	switch err := verifyObject(obj.Oid, obj.Size); {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]string{})
+	case errors.Is(err, os.ErrNotExist):
+		writeError(w, http.StatusNotFound, "object not found")
+	default:
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	}
+}
+
+// handleCreateLock locks a path for the token holder
+func handleCreateLock(w http.ResponseWriter, r *http.Request) {
+	t, ok := authorizeRequest(w, r, git.ReadWriteAccess)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		writeError(w, http.StatusBadRequest, "invalid lock request")
+		return
+	}
+
+	lock, err := createLock(t.repo, req.Path, t.user, t.userKey)
+	switch {
+	case errors.Is(err, ErrLockExists):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{"lock": lock.api(), "message": err.Error()})
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"lock": lock.api()})
+	}
+}
+
+// handleListLocks lists locks matching the query filters
+func handleListLocks(w http.ResponseWriter, r *http.Request) {
+	t, ok := authorizeRequest(w, r, git.ReadOnlyAccess)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	list, next := listLocks(t.repo, query.Get("path"), query.Get("id"), query.Get("cursor"), limit)
+
+	apiLocks := make([]apiLock, 0, len(list))
+	for _, l := range list {
+		apiLocks = append(apiLocks, l.api())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"locks": apiLocks, "next_cursor": next})
+}
+
+// handleVerifyLocks splits the locks into the holder's and everyone else's
+func handleVerifyLocks(w http.ResponseWriter, r *http.Request) {
+	t, ok := authorizeRequest(w, r, git.ReadWriteAccess)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Cursor string `json:"cursor"`
+		Limit  int    `json:"limit"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	list, next := listLocks(t.repo, "", "", req.Cursor, req.Limit)
+	ours, theirs := []apiLock{}, []apiLock{}
+	for _, l := range list {
+		if l.OwnerKey == t.userKey {
+			ours = append(ours, l.api())
+		} else {
+			theirs = append(theirs, l.api())
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ours": ours, "theirs": theirs, "next_cursor": next})
+}
+
+// handleUnlock releases a lock; forcing another user's lock requires admin access
+func handleUnlock(w http.ResponseWriter, r *http.Request) {
+	t, ok := authorizeRequest(w, r, git.ReadWriteAccess)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Force bool `json:"force"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	force := req.Force && authorize(t.repo, t.key) == git.AdminAccess
+	lock, err := deleteLock(t.repo, r.PathValue("id"), t.userKey, force)
+	switch {
+	case errors.Is(err, ErrLockNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrLockNotOwned):
+		writeError(w, http.StatusForbidden, "lock belongs to "+lock.Owner)
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"lock": lock.api()})
+	}
+}
@@ -0,0 +1,128 @@
+package lfs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/git"
+
+	"github.com/nim-sam/gitport/pkg/auth"
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// AuthFunc returns the access level of a key on a repo, like git.Hooks.AuthRepo
+type AuthFunc func(repo string, key ssh.PublicKey) git.AccessLevel
+
+var (
+	repoDir   string
+	storeDir  string
+	authorize AuthFunc
+)
+
+// Init prepares the object store and locks of a repository. Objects live in
+// the repo's .gitport directory and access is checked with authFn.
+func Init(repoPath string, authFn AuthFunc) error {
+	repoDir = repoPath
+	storeDir = filepath.Join(repoPath, ".gitport", "lfs", "objects")
+	authorize = authFn
+
+	if err := os.MkdirAll(filepath.Join(storeDir, "tmp"), 0755); err != nil {
+		return fmt.Errorf("failed to create LFS store: %w", err)
+	}
+
+	if err := loadLocks(filepath.Base(repoPath)); err != nil {
+		return fmt.Errorf("failed to load LFS locks: %w", err)
+	}
+	return nil
+}
+
+/*
+ * Middleware serves the git-lfs-transfer and git-lfs-authenticate SSH commands
+ * that git-lfs runs against SSH remotes. Anything else is passed along.
+ */
+func Middleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			cmd := sess.Command()
+			if len(cmd) < 3 || (cmd[0] != "git-lfs-transfer" && cmd[0] != "git-lfs-authenticate") {
+				next(sess)
+				return
+			}
+
+			repo := strings.TrimSuffix(strings.TrimPrefix(cmd[1], "/"), "/")
+			operation := cmd[2]
+			if operation != "upload" && operation != "download" {
+				wish.Fatalln(sess, "unknown LFS operation:", operation)
+				return
+			}
+
+			pubKey := sess.PublicKey()
+			if pubKey == nil || authorize == nil {
+				wish.Fatalln(sess, "Authentication required")
+				return
+			}
+
+			access := authorize(repo, pubKey)
+			if access == git.NoAccess || (operation == "upload" && access == git.ReadOnlyAccess) {
+				logger.Logger.Warn("LFS access denied", "repo", repo, "operation", operation, "user", sess.User(), "remote", sess.RemoteAddr().String())
+				wish.Fatalln(sess, "Access denied")
+				return
+			}
+
+			userKey := pubKey.Type() + " " + base64.StdEncoding.EncodeToString(pubKey.Marshal())
+			userName := sess.User()
+			if user, ok := auth.GetUserByKey(userKey); ok {
+				userName = user.Name
+			}
+
+			if cmd[0] == "git-lfs-authenticate" {
+				authenticate(sess, repo, pubKey, userName, userKey)
+				return
+			}
+
+			t := &transfer{
+				r:         newPktReader(sess),
+				w:         newPktWriter(sess),
+				repo:      repo,
+				operation: operation,
+				user:      userName,
+				userKey:   userKey,
+				access:    access,
+			}
+			if err := t.serve(); err != nil {
+				logger.Logger.Warn("LFS transfer ended with error", "user", userName, "error", err)
+				sess.Exit(1)
+				return
+			}
+			sess.Exit(0)
+		}
+	}
+}
+
+// authenticate hands the client a token for the LFS HTTP API
+func authenticate(sess ssh.Session, repo string, key ssh.PublicKey, user, userKey string) {
+	if baseURL == "" {
+		wish.Fatalln(sess, "LFS over HTTP is disabled on this server, upgrade to git-lfs 3.0 or newer to use SSH transfers")
+		return
+	}
+
+	value, err := issueToken(repo, key, user, userKey)
+	if err != nil {
+		logger.Logger.Error("Could not issue LFS token", "error", err)
+		wish.Fatalln(sess, "Could not issue LFS token")
+		return
+	}
+
+	json.NewEncoder(sess).Encode(authResponse{
+		Href:      baseURL + "/" + repo + "/info/lfs",
+		Header:    map[string]string{"Authorization": "RemoteAuth " + value},
+		ExpiresIn: int(tokenTTL.Seconds()),
+	})
+	sess.Exit(0)
+}
@@ -0,0 +1,207 @@
+package lfs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+const LocksFile = "lfs_locks.json"
+
+var (
+	ErrLockExists   = errors.New("path is already locked")
+	ErrLockNotFound = errors.New("lock not found")
+	ErrLockNotOwned = errors.New("lock belongs to another user")
+)
+
+// Lock is a file lock on a path of a repository, held by a user identified by
+// their SSH key
+type Lock struct {
+	ID       string    `json:"id"`
+	Repo     string    `json:"repo"`
+	Path     string    `json:"path"`
+	LockedAt time.Time `json:"locked_at"`
+	Owner    string    `json:"owner"`
+	OwnerKey string    `json:"owner_key"`
+}
+
+// lockOwner is the owner object of the LFS locking API
+type lockOwner struct {
+	Name string `json:"name"`
+}
+
+// apiLock is a lock as described by the LFS locking API
+type apiLock struct {
+	ID       string    `json:"id"`
+	Path     string    `json:"path"`
+	LockedAt string    `json:"locked_at"`
+	Owner    lockOwner `json:"owner"`
+}
+
+func (l Lock) api() apiLock {
+	return apiLock{
+		ID:       l.ID,
+		Path:     l.Path,
+		LockedAt: l.LockedAt.Format(time.RFC3339),
+		Owner:    lockOwner{Name: l.Owner},
+	}
+}
+
+var (
+	locks   map[string]map[string]Lock // By repository, then by id
+	locksMu sync.RWMutex
+)
+
+// loadLocks reads the persisted locks from the .gitport directory. Locks saved
+// before they were scoped to a repository belong to defaultRepo.
+func loadLocks(defaultRepo string) error {
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	locks = make(map[string]map[string]Lock)
+
+	data, err := os.ReadFile(filepath.Join(logger.ConfigDir, LocksFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var loaded []Lock
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return err
+	}
+	for _, l := range loaded {
+		if l.Repo == "" {
+			l.Repo = defaultRepo
+		}
+		repoLocks(l.Repo)[l.ID] = l
+	}
+	return nil
+}
+
+// repoLocks returns the locks of a repository, creating its map if needed;
+// callers must hold locksMu for writing
+func repoLocks(repo string) map[string]Lock {
+	if locks[repo] == nil {
+		locks[repo] = make(map[string]Lock)
+	}
+	return locks[repo]
+}
+
+// saveLocks writes the locks of every repository to disk; callers must hold locksMu
+func saveLocks() {
+	var all []Lock
+	for repo := range locks {
+		all = append(all, sortedLocks(repo)...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Repo < all[j].Repo })
+
+	if err := logger.WriteJSONFile(LocksFile, all); err != nil {
+		logger.Logger.Error("Failed to save LFS locks", "error", err)
+	}
+}
+
+// sortedLocks returns the locks of a repository ordered by path; callers must
+// hold locksMu
+func sortedLocks(repo string) []Lock {
+	list := make([]Lock, 0, len(locks[repo]))
+	for _, l := range locks[repo] {
+		list = append(list, l)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
+
+// createLock locks path in repo for the given user, or returns the conflicting lock
+func createLock(repo, path, owner, ownerKey string) (Lock, error) {
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	for _, l := range locks[repo] {
+		if l.Path == path {
+			return l, ErrLockExists
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Lock{}, err
+	}
+
+	lock := Lock{
+		ID:       hex.EncodeToString(id),
+		Repo:     repo,
+		Path:     path,
+		LockedAt: time.Now().UTC().Truncate(time.Second),
+		Owner:    owner,
+		OwnerKey: ownerKey,
+	}
+	repoLocks(repo)[lock.ID] = lock
+	saveLocks()
+
+	logger.Logger.Info("LFS lock created", "repo", repo, "path", path, "user", owner)
+	return lock, nil
+}
+
+// listLocks returns the locks of repo matching path and id (empty matches all),
+// starting after cursor, at most limit at a time. The returned cursor is
+// empty once the last page has been reached.
+func listLocks(repo, path, id, cursor string, limit int) ([]Lock, string) {
+	locksMu.RLock()
+	defer locksMu.RUnlock()
+
+	var matched []Lock
+	for _, l := range sortedLocks(repo) {
+		if (path == "" || l.Path == path) && (id == "" || l.ID == id) {
+			matched = append(matched, l)
+		}
+	}
+
+	start := 0
+	if cursor != "" {
+		for i, l := range matched {
+			if l.ID == cursor {
+				start = i
+				break
+			}
+		}
+	}
+	matched = matched[start:]
+
+	next := ""
+	if limit > 0 && len(matched) > limit {
+		next = matched[limit].ID
+		matched = matched[:limit]
+	}
+	return matched, next
+}
+
+// deleteLock removes a lock of repo. Only its owner may do so unless force is set.
+func deleteLock(repo, id, ownerKey string, force bool) (Lock, error) {
+	locksMu.Lock()
+	defer locksMu.Unlock()
+
+	lock, ok := locks[repo][id]
+	if !ok {
+		return Lock{}, ErrLockNotFound
+	}
+	if lock.OwnerKey != ownerKey && !force {
+		return lock, ErrLockNotOwned
+	}
+
+	delete(locks[repo], id)
+	saveLocks()
+
+	logger.Logger.Info("LFS lock released", "repo", repo, "path", lock.Path, "owner", lock.Owner, "forced", force && lock.OwnerKey != ownerKey)
+	return lock, nil
+}
@@ -0,0 +1,91 @@
+package lfs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// setupLocks starts a test with the given lfs_locks.json contents
+func setupLocks(t *testing.T, saved string) {
+	t.Helper()
+	dir := t.TempDir()
+	previous := logger.ConfigDir
+	logger.ConfigDir = dir
+	t.Cleanup(func() { logger.ConfigDir = previous })
+
+	if saved != "" {
+		if err := os.WriteFile(filepath.Join(dir, LocksFile), []byte(saved), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := loadLocks("game"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocksPerRepo(t *testing.T) {
+	setupLocks(t, "")
+
+	lock, err := createLock("game", "art/hero.psd", "alice", "key-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same path can be locked in another repository
+	if _, err := createLock("web", "art/hero.psd", "bob", "key-b"); err != nil {
+		t.Fatalf("locking the path in another repo: %v", err)
+	}
+	if _, err := createLock("game", "art/hero.psd", "bob", "key-b"); !errors.Is(err, ErrLockExists) {
+		t.Fatalf("locking a locked path = %v, want %v", err, ErrLockExists)
+	}
+
+	tests := []struct {
+		repo  string
+		paths []string
+	}{
+		{repo: "game", paths: []string{"art/hero.psd"}},
+		{repo: "web", paths: []string{"art/hero.psd"}},
+		{repo: "docs"},
+	}
+	for _, tt := range tests {
+		list, _ := listLocks(tt.repo, "", "", "", 0)
+		if len(list) != len(tt.paths) {
+			t.Errorf("locks of %s = %+v, want %v", tt.repo, list, tt.paths)
+		}
+		for _, l := range list {
+			if l.Repo != tt.repo {
+				t.Errorf("lock %s listed for %s belongs to %s", l.ID, tt.repo, l.Repo)
+			}
+		}
+	}
+
+	if _, err := deleteLock("web", lock.ID, "key-a", true); !errors.Is(err, ErrLockNotFound) {
+		t.Errorf("unlocking through another repo = %v, want %v", err, ErrLockNotFound)
+	}
+	if _, err := deleteLock("game", lock.ID, "key-a", false); err != nil {
+		t.Errorf("unlocking: %v", err)
+	}
+
+	// Saved locks keep their repository
+	if err := loadLocks("game"); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := listLocks("web", "", "", "", 0); len(list) != 1 {
+		t.Errorf("locks of web after reloading = %+v, want one", list)
+	}
+	if list, _ := listLocks("game", "", "", "", 0); len(list) != 0 {
+		t.Errorf("locks of game after reloading = %+v, want none", list)
+	}
+}
+
+func TestLoadLegacyLocks(t *testing.T) {
+	// Locks saved before they had a repository belong to the served one
+	setupLocks(t, `[{"id": "abc", "path": "a.bin", "owner": "alice", "owner_key": "key-a"}]`)
+
+	if list, _ := listLocks("game", "", "", "", 0); len(list) != 1 || list[0].Repo != "game" {
+		t.Errorf("locks of game = %+v, want the legacy lock", list)
+	}
+}
@@ -0,0 +1,183 @@
+package lfs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxPktData = 65516
+
+	pktFlush = "0000"
+	pktDelim = "0001"
+)
+
+// pktKind tells data packets apart from the special flush and delim packets
+type pktKind int
+
+const (
+	pktData pktKind = iota
+	pktFlushKind
+	pktDelimKind
+)
+
+var errUnexpectedPkt = errors.New("unexpected packet")
+
+// pktReader reads git pkt-line framed packets
+type pktReader struct {
+	r *bufio.Reader
+}
+
+func newPktReader(r io.Reader) *pktReader {
+	return &pktReader{r: bufio.NewReader(r)}
+}
+
+// readPkt reads the next packet and returns its kind and payload
+func (p *pktReader) readPkt() (pktKind, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(p.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	length, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid pkt-line header %q", header)
+	}
+
+	switch length {
+	case 0:
+		return pktFlushKind, nil, nil
+	case 1:
+		return pktDelimKind, nil, nil
+	case 2, 3:
+		return 0, nil, fmt.Errorf("invalid pkt-line length %d", length)
+	}
+
+	data := make([]byte, length-4)
+	if _, err := io.ReadFull(p.r, data); err != nil {
+		return 0, nil, err
+	}
+	return pktData, data, nil
+}
+
+// readText reads a single text packet, stripping its trailing newline
+func (p *pktReader) readText() (string, pktKind, error) {
+	kind, data, err := p.readPkt()
+	if err != nil {
+		return "", kind, err
+	}
+	return strings.TrimSuffix(string(data), "\n"), kind, nil
+}
+
+// readSection reads text packets until a flush or delim and returns them
+// along with the packet kind that ended the section
+func (p *pktReader) readSection() ([]string, pktKind, error) {
+	var lines []string
+	for {
+		line, kind, err := p.readText()
+		if err != nil {
+			return nil, kind, err
+		}
+		if kind != pktData {
+			return lines, kind, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// dataReader returns a reader over binary data packets up to the next flush
+func (p *pktReader) dataReader() io.Reader {
+	return &pktDataReader{p: p}
+}
+
+type pktDataReader struct {
+	p    *pktReader
+	buf  []byte
+	done bool
+}
+
+func (d *pktDataReader) Read(b []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		kind, data, err := d.p.readPkt()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case pktFlushKind:
+			d.done = true
+		case pktDelimKind:
+			return 0, errUnexpectedPkt
+		default:
+			d.buf = data
+		}
+	}
+	n := copy(b, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// pktWriter writes git pkt-line framed packets
+type pktWriter struct {
+	w *bufio.Writer
+}
+
+func newPktWriter(w io.Writer) *pktWriter {
+	return &pktWriter{w: bufio.NewWriter(w)}
+}
+
+func (p *pktWriter) writeData(data []byte) error {
+	if _, err := fmt.Fprintf(p.w, "%04x", len(data)+4); err != nil {
+		return err
+	}
+	_, err := p.w.Write(data)
+	return err
+}
+
+// writeText writes each line as its own newline-terminated packet
+func (p *pktWriter) writeText(lines ...string) error {
+	for _, line := range lines {
+		if err := p.writeData([]byte(line + "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *pktWriter) writeDelim() error {
+	_, err := p.w.WriteString(pktDelim)
+	return err
+}
+
+// writeFlush ends a message and sends everything buffered so far
+func (p *pktWriter) writeFlush() error {
+	if _, err := p.w.WriteString(pktFlush); err != nil {
+		return err
+	}
+	return p.w.Flush()
+}
+
+// writeStream copies r as binary data packets
+func (p *pktWriter) writeStream(r io.Reader) error {
+	buf := make([]byte, maxPktData)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if werr := p.writeData(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
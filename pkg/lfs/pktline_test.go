@@ -0,0 +1,177 @@
+package lfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// pkt frames s as a data packet
+func pkt(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+func TestReadPkt(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		kind    pktKind
+		data    string
+		wantErr bool
+	}{
+		{name: "flush", input: pktFlush, kind: pktFlushKind},
+		{name: "delim", input: pktDelim, kind: pktDelimKind},
+		{name: "data", input: pkt("version 1\n"), kind: pktData, data: "version 1\n"},
+		{name: "empty data", input: "0004", kind: pktData, data: ""},
+		{name: "invalid header", input: "zzzz", wantErr: true},
+		{name: "reserved length 2", input: "0002", wantErr: true},
+		{name: "reserved length 3", input: "0003", wantErr: true},
+		{name: "truncated payload", input: "000aab", wantErr: true},
+		{name: "truncated header", input: "00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, data, err := newPktReader(strings.NewReader(tt.input)).readPkt()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readPkt(%q) succeeded, want an error", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPkt(%q): %v", tt.input, err)
+			}
+			if kind != tt.kind || string(data) != tt.data {
+				t.Errorf("readPkt(%q) = %v %q, want %v %q", tt.input, kind, data, tt.kind, tt.data)
+			}
+		})
+	}
+}
+
+func TestReadSection(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		lines []string
+		end   pktKind
+	}{
+		{
+			name:  "ended by flush",
+			input: pkt("put-object abc\n") + pkt("size=3\n") + pktFlush,
+			lines: []string{"put-object abc", "size=3"},
+			end:   pktFlushKind,
+		},
+		{
+			name:  "ended by delim",
+			input: pkt("batch\n") + pktDelim + pkt("abc 3\n") + pktFlush,
+			lines: []string{"batch"},
+			end:   pktDelimKind,
+		},
+		{
+			name:  "line without newline",
+			input: pkt("quit") + pktFlush,
+			lines: []string{"quit"},
+			end:   pktFlushKind,
+		},
+		{
+			name:  "empty",
+			input: pktFlush,
+			end:   pktFlushKind,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, end, err := newPktReader(strings.NewReader(tt.input)).readSection()
+			if err != nil {
+				t.Fatalf("readSection: %v", err)
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(tt.lines) || end != tt.end {
+				t.Errorf("readSection = %q %v, want %q %v", lines, end, tt.lines, tt.end)
+			}
+		})
+	}
+}
+
+func TestDataReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		data    string
+		wantErr error
+	}{
+		{name: "single packet", input: pkt("hello") + pktFlush, data: "hello"},
+		{name: "several packets", input: pkt("hel") + pkt("lo ") + pkt("world") + pktFlush, data: "hello world"},
+		{name: "no data", input: pktFlush, data: ""},
+		{name: "delim inside data", input: pkt("hel") + pktDelim, wantErr: errUnexpectedPkt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newPktReader(strings.NewReader(tt.input))
+			data, err := io.ReadAll(r.dataReader())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ReadAll error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if string(data) != tt.data {
+				t.Errorf("ReadAll = %q, want %q", data, tt.data)
+			}
+		})
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "small", size: 10},
+		{name: "one full packet", size: maxPktData},
+		{name: "several packets", size: 2*maxPktData + 123},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := bytes.Repeat([]byte("x"), tt.size)
+
+			var buf bytes.Buffer
+			w := newPktWriter(&buf)
+			if err := w.writeText("status 200", "size=1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.writeDelim(); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.writeStream(bytes.NewReader(payload)); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.writeFlush(); err != nil {
+				t.Fatal(err)
+			}
+
+			r := newPktReader(&buf)
+			lines, end, err := r.readSection()
+			if err != nil || end != pktDelimKind || fmt.Sprint(lines) != "[status 200 size=1]" {
+				t.Fatalf("readSection = %q %v %v", lines, end, err)
+			}
+			data, err := io.ReadAll(r.dataReader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, payload) {
+				t.Errorf("read back %d bytes, want %d", len(data), len(payload))
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package lfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+	"github.com/nim-sam/gitport/pkg/maintenance"
+)
+
+var (
+	ErrInvalidOid   = errors.New("invalid object id")
+	ErrSizeMismatch = errors.New("object size mismatch")
+	ErrHashMismatch = errors.New("object hash mismatch")
+	ErrTooLarge     = errors.New("object too large")
+)
+
+var oidPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// validOid reports whether oid is a lowercase hex sha256 digest
+func validOid(oid string) bool {
+	return oidPattern.MatchString(oid)
+}
+
+// objectPath returns where an object is stored, sharded like git-lfs does locally
+func objectPath(oid string) string {
+	return filepath.Join(storeDir, oid[0:2], oid[2:4], oid)
+}
+
+// objectSize returns the size of a stored object and whether it exists
+func objectSize(oid string) (int64, bool) {
+	if !validOid(oid) {
+		return 0, false
+	}
+	info, err := os.Stat(objectPath(oid))
+	if err != nil {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+// openObject opens a stored object for reading
+func openObject(oid string) (*os.File, int64, error) {
+	if !validOid(oid) {
+		return nil, 0, ErrInvalidOid
+	}
+	file, err := os.Open(objectPath(oid))
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// quota holds the file and repository size limits in bytes, zero meaning
+// unlimited, and how much of the repository limit is already used
+type quota struct {
+	blob, repo int64
+	used       int64
+}
+
+// loadQuota reads the size limits from the server config and measures the
+// repository, the same limits the pre-receive hook applies to pushes
+func loadQuota() quota {
+	config := logger.GetConfig()
+	var q quota
+	q.blob, _ = logger.ParseSize(config.MaxBlobSize)
+	q.repo, _ = logger.ParseSize(config.MaxRepoSize)
+	if q.repo > 0 {
+		q.used, _ = maintenance.RepoSize(repoDir)
+	}
+	return q
+}
+
+// admit checks an object of size against the limits and counts it as used
+func (q *quota) admit(size int64) error {
+	if q.blob > 0 && size > q.blob {
+		return fmt.Errorf("%w: %s exceeds the maximum file size of %s",
+			ErrTooLarge, maintenance.FormatSize(size), maintenance.FormatSize(q.blob))
+	}
+	if q.repo > 0 && q.used+size > q.repo {
+		return fmt.Errorf("%w: repository would grow to %s, over the maximum repository size of %s",
+			ErrTooLarge, maintenance.FormatSize(q.used+size), maintenance.FormatSize(q.repo))
+	}
+	q.used += size
+	return nil
+}
+
+// putObject stores an object read from r, checking it against its oid and size.
+// The object only becomes visible once it has been fully verified.
+func putObject(oid string, size int64, r io.Reader) error {
+	if !validOid(oid) {
+		return ErrInvalidOid
+	}
+	q := loadQuota()
+	if err := q.admit(size); err != nil {
+		return err
+	}
+
+	dest := objectPath(oid)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(storeDir, "tmp"), oid+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	// Read one byte past size so an oversized body is caught without storing it all
+	written, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, size+1))
+	if err != nil {
+		return fmt.Errorf("failed to receive object: %w", err)
+	}
+	if written != size {
+		return ErrSizeMismatch
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != oid {
+		return ErrHashMismatch
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// verifyObject checks that an object exists with the expected size
+func verifyObject(oid string, size int64) error {
+	actual, ok := objectSize(oid)
+	if !ok {
+		return os.ErrNotExist
+	}
+	if actual != size {
+		return ErrSizeMismatch
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package lfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/wish/git"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// transfer serves the git-lfs-transfer protocol for a single SSH session
+type transfer struct {
+	r         *pktReader
+	w         *pktWriter
+	repo      string
+	operation string
+	user      string
+	userKey   string
+	access    git.AccessLevel
+}
+
+// parseArgs turns "key=value" lines into a map
+func parseArgs(lines []string) map[string]string {
+	args := make(map[string]string, len(lines))
+	for _, line := range lines {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			args[key] = value
+		}
+	}
+	return args
+}
+
+// status sends a successful response with optional arguments
+func (t *transfer) status(code int, args ...string) error {
+	if err := t.w.writeText(append([]string{fmt.Sprintf("status %03d", code)}, args...)...); err != nil {
+		return err
+	}
+	return t.w.writeFlush()
+}
+
+// fail sends an error response carrying a message
+func (t *transfer) fail(code int, msg string) error {
+	if err := t.w.writeText(fmt.Sprintf("status %03d", code)); err != nil {
+		return err
+	}
+	if err := t.w.writeDelim(); err != nil {
+		return err
+	}
+	if err := t.w.writeText(msg); err != nil {
+		return err
+	}
+	return t.w.writeFlush()
+}
+
+// lockArgs describes a lock as response arguments
+func lockArgs(l Lock) []string {
+	return []string{
+		"id=" + l.ID,
+		"path=" + l.Path,
+		"locked-at=" + l.LockedAt.Format(time.RFC3339),
+		"ownername=" + l.Owner,
+	}
+}
+
+// serve runs the protocol until the client quits or disconnects
+func (t *transfer) serve() error {
+	// Advertise capabilities
+	if err := t.w.writeText("version=1"); err != nil {
+		return err
+	}
+	if err := t.w.writeFlush(); err != nil {
+		return err
+	}
+
+	for {
+		lines, end, err := t.r.readSection()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		command, arg, _ := strings.Cut(lines[0], " ")
+		args := parseArgs(lines[1:])
+
+		switch command {
+		case "version":
+			if arg != "1" {
+				err = t.fail(400, "unsupported version "+arg)
+			} else {
+				err = t.status(200)
+			}
+		case "batch":
+			err = t.batch(end)
+		case "get-object":
+			err = t.getObject(arg)
+		case "put-object":
+			err = t.putObject(arg, args, end)
+		case "verify-object":
+			err = t.verifyObject(arg, args)
+		case "lock":
+			err = t.lock(args)
+		case "list-lock":
+			err = t.listLocks(args)
+		case "unlock":
+			err = t.unlock(arg, args)
+		case "quit":
+			return t.status(200)
+		default:
+			err = t.fail(400, "unknown command "+command)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// canWrite reports whether the session may upload objects and manage locks
+func (t *transfer) canWrite() bool {
+	return t.operation == "upload" && (t.access == git.ReadWriteAccess || t.access == git.AdminAccess)
+}
+
+// batch answers which objects need to be transferred
+func (t *transfer) batch(end pktKind) error {
+	var objects []string
+	if end == pktDelimKind {
+		var err error
+		if objects, _, err = t.r.readSection(); err != nil {
+			return err
+		}
+	}
+
+	if t.operation == "upload" && !t.canWrite() {
+		return t.fail(403, "write access required")
+	}
+
+	var q quota
+	if t.operation == "upload" {
+		q = loadQuota()
+	}
+
+	response := []string{}
+	for _, line := range objects {
+		parts := strings.Fields(line)
+		if len(parts) < 2 || !validOid(parts[0]) {
+			return t.fail(400, "invalid object "+line)
+		}
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || size < 0 {
+			return t.fail(400, "invalid object "+line)
+		}
+		stored, exists := objectSize(parts[0])
+
+		action := "noop"
+		switch t.operation {
+		case "upload":
+			if !exists || stored != size {
+				if err := q.admit(size); err != nil {
+					logger.Logger.Warn("LFS upload rejected", "oid", parts[0], "user", t.user, "error", err)
+					return t.fail(413, err.Error())
+				}
+				action = "upload"
+			}
+		case "download":
+			if exists {
+				action = "download"
+			}
+		}
+		response = append(response, fmt.Sprintf("%s %d %s", parts[0], size, action))
+	}
+
+	if err := t.w.writeText("status 200"); err != nil {
+		return err
+	}
+	if err := t.w.writeDelim(); err != nil {
+		return err
+	}
+	if err := t.w.writeText(response...); err != nil {
+		return err
+	}
+	return t.w.writeFlush()
+}
+
+// getObject streams a stored object to the client
+func (t *transfer) getObject(oid string) error {
+	file, size, err := openObject(oid)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return t.fail(404, "object not found")
+		}
+		return t.fail(400, err.Error())
+	}
+	defer file.Close()
+
+	if err := t.w.writeText("status 200", fmt.Sprintf("size=%d", size)); err != nil {
+		return err
+	}
+	if err := t.w.writeDelim(); err != nil {
+		return err
+	}
+	if err := t.w.writeStream(file); err != nil {
+		return err
+	}
+
+	logger.Logger.Info("LFS object downloaded", "oid", oid, "size", size, "user", t.user, "repo", t.repo)
+	return t.w.writeFlush()
+}
+
+// putObject receives an object from the client
+func (t *transfer) putObject(oid string, args map[string]string, end pktKind) error {
+	if end != pktDelimKind {
+		return t.fail(400, "missing object data")
+	}
+	data := t.r.dataReader()
+
+	size, err := strconv.ParseInt(args["size"], 10, 64)
+	if err != nil || !t.canWrite() {
+		// Keep the stream in sync before rejecting
+		io.Copy(io.Discard, data)
+		if err != nil {
+			return t.fail(400, "invalid size")
+		}
+		return t.fail(403, "write access required")
+	}
+
+	if err := putObject(oid, size, data); err != nil {
+		logger.Logger.Warn("LFS upload rejected", "oid", oid, "user", t.user, "error", err)
+		if errors.Is(err, ErrTooLarge) {
+			// The object may be huge, so the session ends instead of reading it all
+			if ferr := t.fail(413, err.Error()); ferr != nil {
+				return ferr
+			}
+			return err
+		}
+		io.Copy(io.Discard, data)
+		return t.fail(400, err.Error())
+	}
+
+	logger.Logger.Info("LFS object uploaded", "oid", oid, "size", size, "user", t.user, "repo", t.repo)
+	return t.status(200)
+}
+
+// verifyObject confirms an uploaded object was stored correctly
+func (t *transfer) verifyObject(oid string, args map[string]string) error {
+	size, err := strconv.ParseInt(args["size"], 10, 64)
+	if err != nil {
+		return t.fail(400, "invalid size")
+	}
+
+	switch err := verifyObject(oid, size); {
+	case err == nil:
+		return t.status(200)
+	case errors.Is(err, os.ErrNotExist):
+		return t.fail(404, "object not found")
+	default:
+		return t.fail(409, err.Error())
+	}
+}
+
+// lock creates a lock on a path
+func (t *transfer) lock(args map[string]string) error {
+	if !t.canWrite() {
+		return t.fail(403, "write access required")
+	}
+	path := args["path"]
+	if path == "" {
+		return t.fail(400, "missing path")
+	}
+
+	lock, err := createLock(t.repo, path, t.user, t.userKey)
+	if errors.Is(err, ErrLockExists) {
+		return t.status(409, lockArgs(lock)...)
+	}
+	if err != nil {
+		return t.fail(500, err.Error())
+	}
+	return t.status(201, lockArgs(lock)...)
+}
+
+// listLocks lists locks, marking which ones belong to the session's user
+func (t *transfer) listLocks(args map[string]string) error {
+	limit, _ := strconv.Atoi(args["limit"])
+	list, next := listLocks(t.repo, args["path"], args["id"], args["cursor"], limit)
+
+	header := []string{"status 200"}
+	if next != "" {
+		header = append(header, "next-cursor="+next)
+	}
+	if err := t.w.writeText(header...); err != nil {
+		return err
+	}
+	if err := t.w.writeDelim(); err != nil {
+		return err
+	}
+
+	for _, l := range list {
+		owner := "theirs"
+		if l.OwnerKey == t.userKey {
+			owner = "ours"
+		}
+		if err := t.w.writeText(
+			"lock "+l.ID,
+			fmt.Sprintf("path %s %s", l.ID, l.Path),
+			fmt.Sprintf("locked-at %s %s", l.ID, l.LockedAt.Format(time.RFC3339)),
+			fmt.Sprintf("ownername %s %s", l.ID, l.Owner),
+			fmt.Sprintf("owner %s %s", l.ID, owner),
+		); err != nil {
+			return err
+		}
+	}
+	return t.w.writeFlush()
+}
+
+// unlock releases a lock; forcing another user's lock requires admin access
+func (t *transfer) unlock(id string, args map[string]string) error {
+	if !t.canWrite() {
+		return t.fail(403, "write access required")
+	}
+
+	force := args["force"] == "true" && t.access == git.AdminAccess
+	lock, err := deleteLock(t.repo, id, t.userKey, force)
+	switch {
+	case errors.Is(err, ErrLockNotFound):
+		return t.fail(404, "lock not found")
+	case errors.Is(err, ErrLockNotOwned):
+		return t.fail(403, "lock belongs to "+lock.Owner)
+	case err != nil:
+		return t.fail(500, err.Error())
+	}
+	return t.status(200, lockArgs(lock)...)
+}
@@ -0,0 +1,229 @@
+package lfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/wish/git"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// setupStore points the object store at a temporary repository
+func setupStore(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	repoDir = dir
+	storeDir = filepath.Join(dir, ".gitport", "lfs", "objects")
+	if err := os.MkdirAll(filepath.Join(storeDir, "tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setLimits configures the file and repository size limits for a test
+func setLimits(t *testing.T, maxBlob, maxRepo string) {
+	t.Helper()
+	saved := logger.GetConfig()
+	config := saved
+	config.MaxBlobSize, config.MaxRepoSize = maxBlob, maxRepo
+	logger.SetConfig(config)
+	t.Cleanup(func() { logger.SetConfig(saved) })
+}
+
+// oidOf returns the sha256 object id of content
+func oidOf(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// runSession serves a transfer on the client's packets and returns each
+// response, its status line followed by the lines of its body, and the error
+// the transfer ended with
+func runSession(t *testing.T, operation string, access git.AccessLevel, input string) ([][]string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	tr := &transfer{
+		r:         newPktReader(strings.NewReader(input)),
+		w:         newPktWriter(&out),
+		operation: operation,
+		access:    access,
+	}
+	serveErr := tr.serve()
+
+	r := newPktReader(&out)
+	if lines, _, err := r.readSection(); err != nil || len(lines) != 1 || lines[0] != "version=1" {
+		t.Fatalf("capabilities = %q %v", lines, err)
+	}
+	var responses [][]string
+	for {
+		lines, end, err := r.readSection()
+		if err != nil {
+			break
+		}
+		if end == pktDelimKind {
+			body, _, err := r.readSection()
+			if err != nil {
+				t.Fatalf("reading response body: %v", err)
+			}
+			lines = append(lines, body...)
+		}
+		responses = append(responses, lines)
+	}
+	return responses, serveErr
+}
+
+func TestTransferBatch(t *testing.T) {
+	stored, missing := "stored object", "missing object"
+
+	tests := []struct {
+		name      string
+		operation string
+		access    git.AccessLevel
+		maxBlob   string
+		objects   []string
+		want      []string
+	}{
+		{
+			name:      "upload new and stored objects",
+			operation: "upload",
+			access:    git.ReadWriteAccess,
+			objects:   []string{fmt.Sprintf("%s %d", oidOf(missing), len(missing)), fmt.Sprintf("%s %d", oidOf(stored), len(stored))},
+			want: []string{"status 200",
+				fmt.Sprintf("%s %d upload", oidOf(missing), len(missing)),
+				fmt.Sprintf("%s %d noop", oidOf(stored), len(stored))},
+		},
+		{
+			name:      "download stored and missing objects",
+			operation: "download",
+			access:    git.ReadOnlyAccess,
+			objects:   []string{fmt.Sprintf("%s %d", oidOf(stored), len(stored)), fmt.Sprintf("%s %d", oidOf(missing), len(missing))},
+			want: []string{"status 200",
+				fmt.Sprintf("%s %d download", oidOf(stored), len(stored)),
+				fmt.Sprintf("%s %d noop", oidOf(missing), len(missing))},
+		},
+		{
+			name:      "upload without write access",
+			operation: "upload",
+			access:    git.ReadOnlyAccess,
+			objects:   []string{fmt.Sprintf("%s %d", oidOf(missing), len(missing))},
+			want:      []string{"status 403", "write access required"},
+		},
+		{
+			name:      "upload over the file size limit",
+			operation: "upload",
+			access:    git.ReadWriteAccess,
+			maxBlob:   "4",
+			objects:   []string{fmt.Sprintf("%s %d", oidOf(missing), len(missing))},
+			want:      []string{"status 413", "object too large: 14 B exceeds the maximum file size of 4 B"},
+		},
+		{
+			name:      "stored object over the file size limit",
+			operation: "upload",
+			access:    git.ReadWriteAccess,
+			maxBlob:   "4",
+			objects:   []string{fmt.Sprintf("%s %d", oidOf(stored), len(stored))},
+			want:      []string{"status 200", fmt.Sprintf("%s %d noop", oidOf(stored), len(stored))},
+		},
+		{
+			name:      "invalid size",
+			operation: "upload",
+			access:    git.ReadWriteAccess,
+			objects:   []string{oidOf(missing) + " -1"},
+			want:      []string{"status 400", "invalid object " + oidOf(missing) + " -1"},
+		},
+		{
+			name:      "invalid oid",
+			operation: "download",
+			access:    git.ReadOnlyAccess,
+			objects:   []string{"abc 3"},
+			want:      []string{"status 400", "invalid object abc 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			if err := putObject(oidOf(stored), int64(len(stored)), strings.NewReader(stored)); err != nil {
+				t.Fatal(err)
+			}
+			setLimits(t, tt.maxBlob, "")
+
+			input := pkt("batch\n") + pkt("transfer=basic\n") + pktDelim
+			for _, object := range tt.objects {
+				input += pkt(object + "\n")
+			}
+			input += pktFlush
+
+			responses, err := runSession(t, tt.operation, tt.access, input)
+			if err != nil {
+				t.Fatalf("serve: %v", err)
+			}
+			if len(responses) != 1 || fmt.Sprint(responses[0]) != fmt.Sprint(tt.want) {
+				t.Errorf("responses = %q, want %q", responses, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransferPutObject(t *testing.T) {
+	content := "hello world"
+
+	tests := []struct {
+		name    string
+		oid     string
+		size    string
+		body    string
+		access  git.AccessLevel
+		maxBlob string
+		maxRepo string
+		want    string
+		stored  bool
+		closed  bool // The session ends after the response
+	}{
+		{name: "stored", oid: oidOf(content), size: "11", body: content, access: git.ReadWriteAccess, want: "status 200", stored: true},
+		{name: "body shorter than size", oid: oidOf(content), size: "12", body: content, access: git.ReadWriteAccess, want: "status 400"},
+		{name: "body longer than size", oid: oidOf(content), size: "5", body: content, access: git.ReadWriteAccess, want: "status 400"},
+		{name: "hash mismatch", oid: oidOf("other"), size: "11", body: content, access: git.ReadWriteAccess, want: "status 400"},
+		{name: "invalid size", oid: oidOf(content), size: "eleven", body: content, access: git.ReadWriteAccess, want: "status 400"},
+		{name: "read only", oid: oidOf(content), size: "11", body: content, access: git.ReadOnlyAccess, want: "status 403"},
+		{name: "over the file size limit", oid: oidOf(content), size: "11", body: content, access: git.ReadWriteAccess, maxBlob: "10", want: "status 413", closed: true},
+		{name: "over the repository size limit", oid: oidOf(content), size: "11", body: content, access: git.ReadWriteAccess, maxRepo: "10", want: "status 413", closed: true},
+		{name: "within the limits", oid: oidOf(content), size: "11", body: content, access: git.ReadWriteAccess, maxBlob: "11", maxRepo: "1KB", want: "status 200", stored: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupStore(t)
+			setLimits(t, tt.maxBlob, tt.maxRepo)
+
+			// The object is split over two packets, and followed by a quit
+			// that must still be read once the upload is rejected, unless
+			// the session ends
+			input := pkt("put-object "+tt.oid+"\n") + pkt("size="+tt.size+"\n") + pktDelim +
+				pkt(tt.body[:3]) + pkt(tt.body[3:]) + pktFlush +
+				pkt("quit\n") + pktFlush
+
+			responses, err := runSession(t, "upload", tt.access, input)
+			if tt.closed {
+				if err == nil || len(responses) != 1 || responses[0][0] != tt.want {
+					t.Fatalf("responses = %q, %v, want %q and the session ended", responses, err, tt.want)
+				}
+			} else if err != nil || len(responses) != 2 || responses[0][0] != tt.want || responses[1][0] != "status 200" {
+				t.Fatalf("responses = %q, %v, want %q then the quit", responses, err, tt.want)
+			}
+
+			if _, ok := objectSize(tt.oid); ok != tt.stored {
+				t.Errorf("object stored = %v, want %v", ok, tt.stored)
+			}
+			if leftovers, _ := os.ReadDir(filepath.Join(storeDir, "tmp")); len(leftovers) != 0 {
+				t.Errorf("%d temporary files left behind", len(leftovers))
+			}
+		})
+	}
+}
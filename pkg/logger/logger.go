@@ -1,17 +1,21 @@
 package logger
 
 import (
-	"encoding/csv"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/charmbracelet/log"
 	"github.com/fsnotify/fsnotify"
+
+	"github.com/nim-sam/gitport/pkg/metrics"
 )
 
 const (
@@ -24,16 +28,52 @@ const (
 type ConfigData struct {
 	Public      bool   `json:"public"`
 	DefaultPerm string `json:"default_perm"`
+
+	// MaintenanceInterval is how often repository maintenance runs, as a
+	// Go duration ("24h"). Empty uses the default, "off" disables it.
+	MaintenanceInterval string `json:"maintenance_interval,omitempty"`
+
+	// Storage quotas enforced on push, as sizes like "100MB". Empty means unlimited.
+	MaxPushSize string `json:"max_push_size,omitempty"`
+	MaxBlobSize string `json:"max_blob_size,omitempty"`
+	MaxRepoSize string `json:"max_repo_size,omitempty"`
+
+	// LFSHTTPAddr is the listen address of the Git LFS HTTP API (":8081").
+	// Empty disables it, leaving only SSH transfers.
+	LFSHTTPAddr string `json:"lfs_http_addr,omitempty"`
+
+	// MetricsAddr is the listen address of the Prometheus metrics endpoint
+	// ("127.0.0.1:9100"). Empty disables it.
+	MetricsAddr string `json:"metrics_addr,omitempty"`
+
+	// LogSinks lists where logs are written besides logs.csv
+	LogSinks []SinkConfig `json:"log_sinks,omitempty"`
+
+	// logs.csv is archived once it reaches LogMaxSize ("10MB" by default,
+	// "off" to disable) or is older than LogMaxAge (a Go duration, empty to
+	// disable). LogMaxArchives (10 by default) and LogRetention (a Go
+	// duration) limit how many archives are kept.
+	LogMaxSize     string `json:"log_max_size,omitempty"`
+	LogMaxAge      string `json:"log_max_age,omitempty"`
+	LogMaxArchives int    `json:"log_max_archives,omitempty"`
+	LogRetention   string `json:"log_retention,omitempty"`
 }
 
 var ConfigDir string
 var Config ConfigData
 var configMu sync.RWMutex
 
-// sLogger provides file logging capabilities
+// sLogger writes logs to logs.csv and any configured sinks
 type sLogger struct {
 	LogFile *os.File
 	WorkDir string
+
+	csvLevel int
+	sinks    []configuredSink
+	mu       sync.Mutex
+
+	rotation rotationPolicy
+	openedAt time.Time // Time of the first record in logs.csv
 }
 
 var fileWatcher *fsnotify.Watcher
@@ -45,22 +85,6 @@ var Logger = sLogger{
 	WorkDir: ConfigDir,
 }
 
-// InitTermLogger configures the terminal logger with default settings
-/*
-func InitTermLogger() {
-	// Set global defaults for all loggers (including middleware)
-	log.SetFormatter(log.TextFormatter)
-	log.SetTimeFormat("2006-01-02 15:04:05")
-	log.SetReportTimestamp(true)
-
-	// Apply settings to our terminal logger instance
-	Logger.TermLogger.SetFormatter(log.TextFormatter)
-	Logger.TermLogger.SetTimeFormat("2006-01-02 15:04:05")
-	Logger.TermLogger.SetReportTimestamp(true)
-	Logger.TermLogger.SetColorProfile(termenv.TrueColor)
-}
-*/
-
 // InitFileLogs initializes file-based logging with CSV format
 func (m *sLogger) InitFileLogs(configDir string) *os.File {
 
@@ -72,24 +96,20 @@ func (m *sLogger) InitFileLogs(configDir string) *os.File {
 	}
 
 	filePath := filepath.Join(m.WorkDir, Logs)
-	_, err := os.Stat(filePath)
-	fileExists := err == nil
+	if err := migrateLegacyLogs(filePath); err != nil {
+		log.Error("Could not migrate logs file", "error", err)
+	}
 
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	file, err := openLogFile(filePath)
 	if err != nil {
 		log.Error("Could not open logs file", "error", err)
 		return nil
 	}
 
+	m.mu.Lock()
 	m.LogFile = file
-
-	// Write CSV header if file is new
-	if !fileExists {
-		_, err = file.WriteString("Date,Time,Level,Message\n")
-		if err != nil {
-			log.Error("Could not write CSV header", "error", err)
-		}
-	}
+	m.openedAt = firstRecordTime(filePath)
+	m.mu.Unlock()
 
 	return file
 }
@@ -99,45 +119,26 @@ func SetUsersReloadCallback(callback func() error) {
 	onUsersChanged = callback
 }
 
-// writeCSV writes a log entry to the CSV file with proper formatting
-func (m *sLogger) writeCSV(level string, msg interface{}, keyvals ...interface{}) {
+// writeCSV writes a log entry to the CSV file as a structured record and
+// passes it on to subscribers
+func (m *sLogger) writeCSV(e logEntry) {
 	if m.LogFile == nil {
 		return
 	}
 
-	now := time.Now()
-	date := now.Format("2006-01-02")
-	timeStr := now.Format("15:04:05")
-
-	msgStr := m.formatMessage(msg, keyvals...)
-	line := fmt.Sprintf("%s,%s,%s,%s\n", date, timeStr, level, msgStr)
-	m.LogFile.WriteString(line)
-}
+	r := e.record()
+	publish(r)
 
-// formatMessage formats the message with key-value pairs and proper CSV escaping
-func (m *sLogger) formatMessage(msg interface{}, keyvals ...interface{}) string {
-	msgStr := fmt.Sprintf("%v", msg)
-	if len(keyvals) > 0 {
-		msgStr += " "
-		for i := 0; i < len(keyvals); i += 2 {
-			if i > 0 {
-				msgStr += " "
-			}
-			if i+1 < len(keyvals) {
-				msgStr += fmt.Sprintf("%v=%v", keyvals[i], keyvals[i+1])
-			} else {
-				msgStr += fmt.Sprintf("%v", keyvals[i])
-			}
-		}
+	line, err := EncodeCSV(r)
+	if err != nil {
+		log.Error("Could not encode log record", "error", err)
+		return
 	}
-
-	// Escape quotes and commas in message for CSV
-	msgStr = strings.ReplaceAll(msgStr, "\"", "\"\"")
-	if strings.ContainsAny(msgStr, ",\n\"") {
-		msgStr = "\"" + msgStr + "\""
+	// A single write keeps lines whole when hooks append to the same file
+	m.LogFile.Write(line)
+	if m.openedAt.IsZero() {
+		m.openedAt = e.time
 	}
-
-	return msgStr
 }
 
 // Info logs an informational message
@@ -155,10 +156,25 @@ func (m *sLogger) Error(msg interface{}, keyvals ...interface{}) {
 	m.log("ERROR", msg, keyvals...)
 }
 
-// log is a helper method to write logs to both file and terminal
+// log is a helper method to write logs to the CSV file and every sink that accepts the level
 func (m *sLogger) log(level string, msg interface{}, keyvals ...interface{}) {
-	if m.LogFile != nil {
-		m.writeCSV(level, msg, keyvals...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rank := levelRank(level)
+	entry := logEntry{time: time.Now(), level: level, msg: fmt.Sprintf("%v", msg), keyvals: keyvals}
+
+	if m.LogFile != nil && rank >= m.csvLevel {
+		m.writeCSV(entry)
+		m.rotateIfDue(entry.time)
+	}
+
+	for _, s := range m.sinks {
+		if rank >= s.minLevel {
+			if err := s.write(entry); err != nil {
+				log.Error("Could not write to log sink", "type", s.kind, "error", err)
+			}
+		}
 	}
 }
 
@@ -230,6 +246,11 @@ func handleFileEvent(event fsnotify.Event) {
 
 // reloadModifiedFile reloads the configuration when a file is modified
 func reloadModifiedFile(fileName, filePath string) {
+	switch fileName {
+	case Users, Conf:
+		metrics.WatcherReloads.Inc(fileName)
+	}
+
 	switch fileName {
 	case Users:
 		if onUsersChanged != nil {
@@ -258,11 +279,12 @@ func tryReAddToWatcher(filePath string) {
 	}(filePath)
 }
 
-// CloseFileWatcher closes the file watcher
+// CloseFileWatcher closes the file watcher and the log sinks it may have reconfigured
 func CloseFileWatcher() {
 	if fileWatcher != nil {
 		fileWatcher.Close()
 	}
+	Logger.CloseSinks()
 }
 
 // GetConfigPublic safely reads the Public config field
@@ -279,18 +301,34 @@ func GetConfigDefaultPerm() string {
 	return Config.DefaultPerm
 }
 
+// GetConfig safely returns a copy of the whole config
+func GetConfig() ConfigData {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return Config
+}
+
 // SetConfig safely updates the config with write lock
 func SetConfig(newConfig ConfigData) {
 	configMu.Lock()
-	defer configMu.Unlock()
 	Config = newConfig
+	configMu.Unlock()
 	Logger.Info("Config updated in memory", "public", newConfig.Public, "default_perm", newConfig.DefaultPerm)
 }
 
 // ReloadConfig reloads config from disk (called when file changes)
 func ReloadConfig() error {
 	Logger.Info("Detected external change, reloading config", "file", Conf)
+	if err := LoadConfig(); err != nil {
+		return err
+	}
+	Logger.ConfigureSinks(GetConfig().LogSinks)
+	Logger.ConfigureRotation(GetConfig())
+	return nil
+}
 
+// LoadConfig reads config.json from ConfigDir into memory
+func LoadConfig() error {
 	file, err := os.Open(filepath.Join(ConfigDir, Conf))
 	if err != nil {
 		return err
@@ -306,6 +344,32 @@ func ReloadConfig() error {
 	return nil
 }
 
+// ParseSize reads a size such as "512", "100MB" or "2GiB" into bytes.
+// Units are powers of 1024; an empty string means zero (no limit).
+func ParseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	units := []string{"K", "M", "G", "T"}
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(value, "B"), "I"), " ")
+	for i, u := range units {
+		if strings.HasSuffix(trimmed, u) {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, u))
+			multiplier = int64(1) << (10 * (i + 1))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
 // WriteJSONFile writes JSON data to a file with watcher suspension
 func WriteJSONFile(filename string, data interface{}) error {
 	if ConfigDir == "" {
@@ -360,25 +424,93 @@ func writeJSONToFile(filePath, filename string, data interface{}) error {
 	return err
 }
 
-// ReadLogs reads the logs.csv file and returns it as a list of lists.
-// Each inner list represents a row: [Date, Time, Level, Message]
-func ReadLogs() ([][]string, error) {
+// logPageChunk is how much of logs.csv ReadLogs reads at a time while
+// looking for the start of a page
+const logPageChunk = 64 << 10
+
+// ReadLogs reads up to limit records of the current logs.csv that come before
+// offset end, oldest first, and returns the offset of the first one so the
+// page before it can be read next. An end below zero reads the newest records
+// and a limit of zero reads everything before end. The returned offset is zero
+// once the start of the file is reached. Rows that can't be parsed are
+// skipped. Older records are in the archives listed by LogArchives.
+func ReadLogs(end int64, limit int) ([]Record, int64, error) {
 	if ConfigDir == "" {
-		return nil, fmt.Errorf("ConfigDir not set")
+		return nil, 0, fmt.Errorf("ConfigDir not set")
 	}
 
 	filePath := filepath.Join(ConfigDir, Logs)
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return nil, 0, fmt.Errorf("failed to open log file: %w", err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	info, err := file.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		return nil, 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	if end < 0 || end > info.Size() {
+		end = info.Size()
 	}
 
-	return records, nil
+	start := int64(0)
+	if limit > 0 {
+		if start, err = pageStart(file, end, limit); err != nil {
+			return nil, 0, fmt.Errorf("failed to read log file: %w", err)
+		}
+	}
+
+	records, err := readRecords(io.NewSectionReader(file, start, end-start))
+	if err != nil {
+		return records, start, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	return records, start, nil
+}
+
+// pageStart reads file backwards from end until it has found the starts of
+// limit records, and returns the offset of the earliest one, or zero if the
+// file has fewer records
+func pageStart(file *os.File, end int64, limit int) (int64, error) {
+	var buf []byte
+	found := 0
+	next := end // Start of the last record found
+	pos := end
+	for pos > 0 {
+		n := min(int64(logPageChunk), pos)
+		pos -= n
+		chunk := make([]byte, n, int(n)+len(buf))
+		if _, err := file.ReadAt(chunk, pos); err != nil {
+			return 0, err
+		}
+		buf = append(chunk, buf...)
+
+		// Only lines starting in the new chunk are checked
+		for i := int(n); i > 0; i-- {
+			if buf[i-1] != '\n' || !isRecordStart(buf[i:]) {
+				continue
+			}
+			// A line of a quoted message can begin with a time too, but it
+			// leaves an odd number of quotes before the next record
+			if bytes.Count(buf[i:next-pos], []byte{'"'})%2 != 0 {
+				continue
+			}
+			next = pos + int64(i)
+			if found++; found == limit {
+				return next, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// isRecordStart reports whether a logs.csv line begins with a record's time
+func isRecordStart(line []byte) bool {
+	head := line[:min(len(line), len(time.RFC3339Nano)+1)]
+	stamp, _, ok := bytes.Cut(head, []byte(","))
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(time.RFC3339, string(stamp))
+	return err == nil
 }
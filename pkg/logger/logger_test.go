@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "512", want: 512},
+		{value: "100B", want: 100},
+		{value: "1K", want: 1 << 10},
+		{value: "1KB", want: 1 << 10},
+		{value: "100MB", want: 100 << 20},
+		{value: "2GiB", want: 2 << 30},
+		{value: "1TB", want: 1 << 40},
+		{value: "1.5k", want: 1536},
+		{value: " 10 MB ", want: 10 << 20},
+		{value: "lots", wantErr: true},
+		{value: "-1MB", wantErr: true},
+		{value: "MB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSize(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReadLogs(t *testing.T) {
+	dir := t.TempDir()
+	previous := ConfigDir
+	ConfigDir = dir
+	t.Cleanup(func() { ConfigDir = previous })
+
+	file, err := openLogFile(filepath.Join(dir, Logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var messages []string
+	for i := 0; i < 500; i++ {
+		msg := fmt.Sprintf("entry %d", i)
+		if i%7 == 0 {
+			// Lines of quoted messages aren't records, even when they look like one
+			msg += "\n" + base.Format(time.RFC3339) + `,INFO,"not" a record`
+		}
+		line, err := EncodeCSV(Record{Time: base.Add(time.Duration(i) * time.Second), Level: "INFO", Message: msg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write(line)
+		messages = append(messages, msg)
+	}
+	file.Close()
+
+	tests := []struct {
+		name  string
+		limit int
+	}{
+		{name: "everything", limit: 0},
+		{name: "single records", limit: 1},
+		{name: "pages", limit: 120},
+		{name: "larger than the file", limit: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			end, pages := int64(-1), 0
+			for end != 0 {
+				records, start, err := ReadLogs(end, tt.limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.limit > 0 && len(records) > tt.limit {
+					t.Fatalf("page has %d records, want at most %d", len(records), tt.limit)
+				}
+				page := make([]string, len(records))
+				for i, r := range records {
+					page[i] = r.Message
+				}
+				got = append(page, got...)
+				end = start
+				if pages++; pages > len(messages)+1 {
+					t.Fatal("paging never reached the start of the file")
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(messages, "|") {
+				t.Errorf("read %d records across %d pages, want the %d written in order", len(got), pages, len(messages))
+			}
+		})
+	}
+}
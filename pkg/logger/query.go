@@ -0,0 +1,193 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Queries stores the log queries saved from the TUI
+const Queries = "log_queries.json"
+
+// Query selects log records. The zero Query matches everything.
+type Query struct {
+	// Levels the record must have one of, upper case. Empty allows any level.
+	Levels []string
+	// Fields maps field names, lower case, to text their values must contain
+	Fields map[string]string
+	// Since and Until bound the record's time when set
+	Since, Until time.Time
+	// Text must all appear in the message or field values
+	Text []string
+}
+
+// ParseQuery reads a query such as `level:ERROR user:alice since:2h "push"`.
+// Terms are ANDed; a key may list alternatives separated by commas. since and
+// until, or after and before, accept durations ago ("2h"), dates
+// ("2024-05-01") or RFC 3339 times. Keys are lower cased, while values and
+// text keep their case for Match to compare without it.
+func ParseQuery(input string) (Query, error) {
+	var q Query
+	terms, err := splitQuery(input)
+	if err != nil {
+		return q, err
+	}
+
+	now := time.Now()
+	for _, term := range terms {
+		key, value, found := strings.Cut(term.text, ":")
+		if term.quoted || !found || key == "" || value == "" {
+			q.Text = append(q.Text, term.text)
+			continue
+		}
+
+		switch key = strings.ToLower(key); key {
+		case "level":
+			for _, level := range strings.Split(value, ",") {
+				q.Levels = append(q.Levels, strings.ToUpper(strings.TrimSpace(level)))
+			}
+		case "since", "until", "after", "before":
+			t, err := parseQueryTime(value, now)
+			if err != nil {
+				return q, fmt.Errorf("%s: %w", key, err)
+			}
+			if key == "since" || key == "after" {
+				q.Since = t
+			} else {
+				q.Until = t
+			}
+		default:
+			if q.Fields == nil {
+				q.Fields = make(map[string]string)
+			}
+			q.Fields[key] = value
+		}
+	}
+	return q, nil
+}
+
+// queryTerm is a word or quoted phrase of a query
+type queryTerm struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery splits a query on spaces, keeping quoted phrases together. A
+// quoted value after a key (user:"a b") stays part of its term.
+func splitQuery(input string) ([]queryTerm, error) {
+	var terms []queryTerm
+	var current strings.Builder
+	inQuotes, quoted := false, false
+
+	flush := func() {
+		if current.Len() > 0 || quoted {
+			terms = append(terms, queryTerm{text: current.String(), quoted: quoted})
+		}
+		current.Reset()
+		quoted = false
+	}
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			// Only a phrase quoted from its start is free text
+			if !inQuotes && current.Len() == 0 {
+				quoted = true
+			}
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	flush()
+	return terms, nil
+}
+
+// parseQueryTime reads a duration ago, a date or an RFC 3339 time
+func parseQueryTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if strings.HasSuffix(value, "d") {
+		var days int
+		if _, err := fmt.Sscanf(value, "%dd", &days); err == nil {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// Match reports whether a record satisfies every term of the query
+func (q Query) Match(r Record) bool {
+	if len(q.Levels) > 0 {
+		found := false
+		for _, level := range q.Levels {
+			if r.Level == level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && r.Time.After(q.Until) {
+		return false
+	}
+
+	for key, want := range q.Fields {
+		value, ok := r.Fields[key]
+		if !ok || !strings.Contains(strings.ToLower(value), strings.ToLower(want)) {
+			return false
+		}
+	}
+
+	if len(q.Text) > 0 {
+		haystack := strings.ToLower(r.Message + " " + r.FieldString())
+		for _, text := range q.Text {
+			if !strings.Contains(haystack, strings.ToLower(text)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SavedQueries returns the queries saved in log_queries.json
+func SavedQueries() []string {
+	data, err := os.ReadFile(filepath.Join(ConfigDir, Queries))
+	if err != nil {
+		return nil
+	}
+
+	var queries []string
+	if err := json.Unmarshal(data, &queries); err != nil {
+		Logger.Warn("Could not parse saved log queries", "file", Queries, "error", err)
+		return nil
+	}
+	return queries
+}
+
+// SaveQueries replaces the saved log queries
+func SaveQueries(queries []string) error {
+	return WriteJSONFile(Queries, queries)
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Query
+		wantErr bool
+	}{
+		{name: "empty", input: "  "},
+		{name: "levels", input: "level:error,Warn", want: Query{Levels: []string{"ERROR", "WARN"}}},
+		{
+			name:  "keys are lower cased, values and text keep their case",
+			input: `User:Alice repo:GitPort Push`,
+			want:  Query{Fields: map[string]string{"user": "Alice", "repo": "GitPort"}, Text: []string{"Push"}},
+		},
+		{name: "quoted phrase", input: `"Auth failed: bad key"`, want: Query{Text: []string{"Auth failed: bad key"}}},
+		{name: "quoted value", input: `user:"Jane Doe"`, want: Query{Fields: map[string]string{"user": "Jane Doe"}}},
+		{name: "empty key or value is text", input: ":x y:", want: Query{Text: []string{":x", "y:"}}},
+		{
+			name:  "date and time",
+			input: "since:2024-05-01 UNTIL:2024-05-02T10:00:00Z",
+			want: Query{
+				Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+				Until: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:  "after and before",
+			input: "after:2024-05-01 before:2024-05-02",
+			want: Query{
+				Since: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+				Until: time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local),
+			},
+		},
+		{name: "invalid time", input: "since:yesterday", wantErr: true},
+		{name: "unterminated quote", input: `"push`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuery(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Since.Equal(tt.want.Since) || !got.Until.Equal(tt.want.Until) {
+				t.Errorf("ParseQuery(%q) range = %v..%v, want %v..%v", tt.input, got.Since, got.Until, tt.want.Since, tt.want.Until)
+			}
+			got.Since, got.Until = time.Time{}, time.Time{}
+			tt.want.Since, tt.want.Until = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryDurations(t *testing.T) {
+	before := time.Now()
+	q, err := ParseQuery("since:2h until:3d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := before.Add(-2 * time.Hour); q.Since.Before(want) || q.Since.After(time.Now().Add(-2*time.Hour)) {
+		t.Errorf("since:2h = %v, want about %v", q.Since, want)
+	}
+	if want := before.AddDate(0, 0, -3); q.Until.Before(want) || q.Until.After(time.Now().AddDate(0, 0, -3)) {
+		t.Errorf("until:3d = %v, want about %v", q.Until, want)
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	record := Record{
+		Time:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Level:   "WARN",
+		Message: "Push rejected",
+		Fields:  map[string]string{"user": "Alice", "repo": "GitPort"},
+	}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "", want: true},
+		{input: "level:warn,error", want: true},
+		{input: "level:info", want: false},
+		{input: "user:alice", want: true},
+		{input: "USER:ALI", want: true},
+		{input: "user:bob", want: false},
+		{input: "branch:main", want: false},
+		{input: `"push REJECTED"`, want: true},
+		{input: "gitport", want: true},
+		{input: "push accepted", want: false},
+		{input: "since:2024-05-01T11:00:00Z until:2024-05-01T12:00:00Z", want: true},
+		{input: "since:2024-05-01T12:00:01Z", want: false},
+		{input: "until:2024-05-01T11:59:59Z", want: false},
+	}
+
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.input)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", tt.input, err)
+		}
+		if got := q.Match(record); got != tt.want {
+			t.Errorf("%q matched = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Header of logs.csv. Fields holds a JSON object of the record's key/value pairs.
+var CSVHeader = []string{"Timestamp", "Level", "Message", "Fields"}
+
+// legacyCSVHeader is the header of logs.csv files written before records were structured
+var legacyCSVHeader = []string{"Date", "Time", "Level", "Message"}
+
+// Record is a single structured log entry
+type Record struct {
+	Time    time.Time
+	Level   string
+	Message string
+	Fields  map[string]string
+}
+
+// FieldString renders the fields as sorted "key=value" pairs
+func (r Record) FieldString() string {
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+r.Fields[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// MarshalJSON renders the record as a flat object with time, level and msg
+// keys next to its fields. Fields named like those keys get a "field." prefix.
+func (r Record) MarshalJSON() ([]byte, error) {
+	object := map[string]string{
+		"time":  r.Time.Format(time.RFC3339Nano),
+		"level": r.Level,
+		"msg":   r.Message,
+	}
+	for key, value := range r.Fields {
+		if _, taken := object[key]; taken {
+			key = "field." + key
+		}
+		object[key] = value
+	}
+	return json.Marshal(object)
+}
+
+// record converts a log call into a structured record
+func (e logEntry) record() Record {
+	r := Record{Time: e.time, Level: e.level, Message: e.msg}
+	if len(e.keyvals) > 0 {
+		r.Fields = make(map[string]string, len(e.keyvals)/2)
+	}
+	for i := 0; i < len(e.keyvals); i += 2 {
+		if i+1 < len(e.keyvals) {
+			r.Fields[keyvalString(e.keyvals[i])] = keyvalString(e.keyvals[i+1])
+		} else {
+			// A key without a value is kept rather than dropped
+			r.Fields["extra"] = keyvalString(e.keyvals[i])
+		}
+	}
+	return r
+}
+
+// EncodeCSV renders a record as a single logs.csv line
+func EncodeCSV(r Record) ([]byte, error) {
+	fields := "{}"
+	if len(r.Fields) > 0 {
+		data, err := json.Marshal(r.Fields)
+		if err != nil {
+			return nil, err
+		}
+		fields = string(data)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{r.Time.Format(time.RFC3339), r.Level, r.Message, fields})
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// decodeCSV parses a logs.csv row into a record
+func decodeCSV(row []string) (Record, error) {
+	if len(row) < 4 {
+		return Record{}, fmt.Errorf("expected 4 columns, got %d", len(row))
+	}
+
+	t, err := time.Parse(time.RFC3339, row[0])
+	if err != nil {
+		return Record{}, err
+	}
+
+	r := Record{Time: t, Level: row[1], Message: row[2]}
+	if row[3] != "" && row[3] != "{}" {
+		if err := json.Unmarshal([]byte(row[3]), &r.Fields); err != nil {
+			return Record{}, err
+		}
+	}
+	return r, nil
+}
+
+// legacyFieldPattern finds the "key=" markers flattened into old messages
+var legacyFieldPattern = regexp.MustCompile(`(?:^| )([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// parseLegacyRow recovers a record from a "Date,Time,Level,Message" row,
+// splitting the "k=v" pairs back out of the message
+func parseLegacyRow(row []string) (Record, error) {
+	if len(row) < 4 {
+		return Record{}, fmt.Errorf("expected 4 columns, got %d", len(row))
+	}
+
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", row[0]+" "+row[1], time.Local)
+	if err != nil {
+		return Record{}, err
+	}
+
+	r := Record{Time: t, Level: row[2], Message: row[3]}
+
+	matches := legacyFieldPattern.FindAllStringSubmatchIndex(row[3], -1)
+	if len(matches) == 0 {
+		return r, nil
+	}
+
+	r.Message = strings.TrimSpace(row[3][:matches[0][0]])
+	r.Fields = make(map[string]string, len(matches))
+	for i, match := range matches {
+		end := len(row[3])
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		r.Fields[row[3][match[2]:match[3]]] = row[3][match[1]:end]
+	}
+	return r, nil
+}
+
+// migrateLegacyLogs rewrites a logs.csv file from the old flattened format,
+// keeping the original next to it as logs.v1.csv. Files already in the
+// current format are left alone.
+func migrateLegacyLogs(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	header, _ := bufio.NewReader(file).ReadString('\n')
+	if strings.TrimSpace(header) != strings.Join(legacyCSVHeader, ",") {
+		file.Close()
+		return nil
+	}
+
+	file.Seek(0, io.SeekStart)
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to parse legacy logs: %w", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(strings.Join(CSVHeader, ",") + "\n")
+	for _, row := range rows[1:] {
+		r, err := parseLegacyRow(row)
+		if err != nil {
+			continue
+		}
+		line, err := EncodeCSV(r)
+		if err != nil {
+			continue
+		}
+		out.Write(line)
+	}
+
+	backup := strings.TrimSuffix(filePath, ".csv") + ".v1.csv"
+	if err := os.Rename(filePath, backup); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, out.Bytes(), 0644)
+}
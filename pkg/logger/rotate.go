@@ -0,0 +1,372 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// Archives are named logs-<rotation time>.csv.gz so they sort by age
+	archivePrefix     = "logs-"
+	archiveSuffix     = ".csv.gz"
+	archiveTimeFormat = "20060102-150405.000"
+
+	defaultLogMaxSize     = 10 << 20
+	defaultLogMaxArchives = 10
+)
+
+// rotationPolicy decides when logs.csv is archived and how long archives are kept
+type rotationPolicy struct {
+	maxSize     int64
+	maxAge      time.Duration
+	maxArchives int
+	retention   time.Duration
+}
+
+// archiveMu serialises compressing and pruning archives
+var archiveMu sync.Mutex
+
+// ConfigureRotation applies the log rotation settings from config. Only the
+// server rotates logs.csv; hook processes append to it without rotating.
+func (m *sLogger) ConfigureRotation(config ConfigData) {
+	policy := rotationPolicy{maxSize: defaultLogMaxSize, maxArchives: defaultLogMaxArchives}
+	var invalid []string
+
+	switch strings.ToLower(strings.TrimSpace(config.LogMaxSize)) {
+	case "":
+	case "off", "0":
+		policy.maxSize = 0
+	default:
+		if size, err := ParseSize(config.LogMaxSize); err == nil {
+			policy.maxSize = size
+		} else {
+			invalid = append(invalid, "log_max_size")
+		}
+	}
+
+	if config.LogMaxAge != "" {
+		if age, err := time.ParseDuration(config.LogMaxAge); err == nil && age > 0 {
+			policy.maxAge = age
+		} else {
+			invalid = append(invalid, "log_max_age")
+		}
+	}
+
+	if config.LogMaxArchives > 0 {
+		policy.maxArchives = config.LogMaxArchives
+	}
+
+	if config.LogRetention != "" {
+		if retention, err := time.ParseDuration(config.LogRetention); err == nil && retention > 0 {
+			policy.retention = retention
+		} else {
+			invalid = append(invalid, "log_retention")
+		}
+	}
+
+	m.mu.Lock()
+	m.rotation = policy
+	dir := m.WorkDir
+	m.mu.Unlock()
+
+	for _, key := range invalid {
+		m.Warn("Invalid log rotation setting in config, using the default", "key", key)
+	}
+
+	// Archives left uncompressed by a previous run are finished off here
+	go compressArchives(dir, policy)
+}
+
+// rotateIfDue archives logs.csv once it's too large or too old. The caller holds m.mu.
+func (m *sLogger) rotateIfDue(now time.Time) {
+	p := m.rotation
+	sizeDue := p.maxSize > 0 && m.logSize() >= p.maxSize
+	ageDue := p.maxAge > 0 && !m.openedAt.IsZero() && now.Sub(m.openedAt) >= p.maxAge
+	if !sizeDue && !ageDue {
+		return
+	}
+
+	filePath := filepath.Join(m.WorkDir, Logs)
+	archive := filepath.Join(m.WorkDir, archivePrefix+now.UTC().Format(archiveTimeFormat)+".csv")
+
+	m.LogFile.Close()
+	if err := os.Rename(filePath, archive); err != nil {
+		log.Error("Could not rotate logs file", "error", err)
+	}
+
+	file, err := openLogFile(filePath)
+	if err != nil {
+		log.Error("Could not reopen logs file", "error", err)
+		m.LogFile = nil
+		return
+	}
+	m.LogFile = file
+	m.openedAt = time.Time{}
+
+	go compressArchives(m.WorkDir, p)
+}
+
+// logSize returns the size of logs.csv. It's read from the file rather than
+// counted here, since hook processes append to it too. The caller holds m.mu.
+func (m *sLogger) logSize() int64 {
+	info, err := m.LogFile.Stat()
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// openLogFile opens logs.csv for appending, writing the header if it's new
+func openLogFile(filePath string) (*os.File, error) {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.Size() == 0 {
+		if _, err := file.WriteString(strings.Join(CSVHeader, ",") + "\n"); err != nil {
+			log.Error("Could not write CSV header", "error", err)
+		}
+	}
+	return file, nil
+}
+
+// firstRecordTime returns the time of the oldest record in a logs file, or
+// the zero time if it has none
+func firstRecordTime(filePath string) time.Time {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	for i := 0; i < 2; i++ {
+		row, err := reader.Read()
+		if err != nil {
+			return time.Time{}
+		}
+		if r, err := decodeCSV(row); err == nil {
+			return r.Time
+		}
+	}
+	return time.Time{}
+}
+
+// compressArchives gzips rotated logs files and prunes old archives
+func compressArchives(dir string, p rotationPolicy) {
+	if dir == "" {
+		return
+	}
+
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+
+	pending, _ := filepath.Glob(filepath.Join(dir, archivePrefix+"*.csv"))
+	for _, path := range pending {
+		if err := gzipFile(path); err != nil {
+			Logger.Error("Could not compress logs archive", "file", filepath.Base(path), "error", err)
+			continue
+		}
+		Logger.Info("Archived logs file", "file", filepath.Base(path)+".gz")
+	}
+
+	pruneArchives(dir, p)
+}
+
+// gzipFile replaces path with a gzip compressed path.gz
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
+
+// pruneArchives deletes archives beyond the retention count or age
+func pruneArchives(dir string, p rotationPolicy) {
+	names, err := archiveNames(dir)
+	if err != nil {
+		return
+	}
+
+	for i, name := range names {
+		expired := p.retention > 0 && time.Since(archiveTime(name)) > p.retention
+		if i < p.maxArchives && !expired {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			Logger.Error("Could not delete logs archive", "file", name, "error", err)
+			continue
+		}
+		Logger.Info("Deleted old logs archive", "file", name)
+	}
+}
+
+// archiveNames lists the compressed archives in dir, newest first
+func archiveNames(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, archivePrefix+"*"+archiveSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// archiveTime reads the rotation time from an archive's name
+func archiveTime(name string) time.Time {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, archivePrefix), archiveSuffix)
+	t, _ := time.Parse(archiveTimeFormat, stamp)
+	return t
+}
+
+// LogArchives lists the rotated logs archives, newest first
+func LogArchives() ([]string, error) {
+	if ConfigDir == "" {
+		return nil, nil
+	}
+	return archiveNames(ConfigDir)
+}
+
+// ReadLogArchive reads the records of a rotated logs archive, oldest first
+func ReadLogArchive(name string) ([]Record, error) {
+	var records []Record
+	err := eachArchiveRecord(name, func(r Record) error {
+		records = append(records, r)
+		return nil
+	})
+	return records, err
+}
+
+// eachArchiveRecord streams the records of a rotated logs archive to fn,
+// oldest first
+func eachArchiveRecord(name string, fn func(Record) error) error {
+	if filepath.Base(name) != name || !strings.HasPrefix(name, archivePrefix) || !strings.HasSuffix(name, archiveSuffix) {
+		return os.ErrNotExist
+	}
+
+	file, err := os.Open(filepath.Join(ConfigDir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	zr, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	return eachRecord(zr, fn)
+}
+
+// ReadLogsSince streams the records of the archives and logs.csv to fn, oldest
+// first, skipping archives rotated before since. A zero since reads them all.
+// An error returned by fn stops the reading.
+func ReadLogsSince(since time.Time, fn func(Record) error) error {
+	names, err := LogArchives()
+	if err != nil {
+		return err
+	}
+
+	for i := len(names) - 1; i >= 0; i-- {
+		if !since.IsZero() && archiveTime(names[i]).Before(since) {
+			continue
+		}
+		if err := eachArchiveRecord(names[i], fn); err != nil {
+			return fmt.Errorf("failed to read %s: %w", names[i], err)
+		}
+	}
+
+	file, err := os.Open(filepath.Join(ConfigDir, Logs))
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+	return eachRecord(file, fn)
+}
+
+// readRecords reads every record of a logs file, skipping the header and any
+// rows that can't be parsed
+func readRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	err := eachRecord(r, func(rec Record) error {
+		records = append(records, rec)
+		return nil
+	})
+	return records, err
+}
+
+// eachRecord streams the records of a logs file to fn, skipping the header
+// and any rows that can't be parsed
+func eachRecord(r io.Reader, fn func(Record) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			return err
+		}
+		if rec, err := decodeCSV(row); err == nil {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	}
+}
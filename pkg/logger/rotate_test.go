@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigureRotation(t *testing.T) {
+	defaults := rotationPolicy{maxSize: defaultLogMaxSize, maxArchives: defaultLogMaxArchives}
+
+	tests := []struct {
+		name   string
+		config ConfigData
+		want   rotationPolicy
+	}{
+		{name: "defaults", config: ConfigData{}, want: defaults},
+		{name: "size off", config: ConfigData{LogMaxSize: "off"}, want: rotationPolicy{maxArchives: defaultLogMaxArchives}},
+		{name: "size zero", config: ConfigData{LogMaxSize: "0"}, want: rotationPolicy{maxArchives: defaultLogMaxArchives}},
+		{name: "size", config: ConfigData{LogMaxSize: "5MB"}, want: rotationPolicy{maxSize: 5 << 20, maxArchives: defaultLogMaxArchives}},
+		{name: "invalid size keeps the default", config: ConfigData{LogMaxSize: "lots"}, want: defaults},
+		{
+			name:   "age, archives and retention",
+			config: ConfigData{LogMaxAge: "24h", LogMaxArchives: 3, LogRetention: "720h"},
+			want:   rotationPolicy{maxSize: defaultLogMaxSize, maxAge: 24 * time.Hour, maxArchives: 3, retention: 720 * time.Hour},
+		},
+		{name: "invalid age and retention are ignored", config: ConfigData{LogMaxAge: "daily", LogRetention: "-1h"}, want: defaults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No WorkDir, so there are no archives to finish off
+			var m sLogger
+			m.ConfigureRotation(tt.config)
+			if m.rotation != tt.want {
+				t.Errorf("policy = %+v, want %+v", m.rotation, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateIfDue(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		policy   rotationPolicy
+		size     int64
+		openedAt time.Time
+		rotated  bool
+	}{
+		{name: "below the size limit", policy: rotationPolicy{maxSize: 1000, maxArchives: 5}, size: 999},
+		{name: "at the size limit", policy: rotationPolicy{maxSize: 1000, maxArchives: 5}, size: 1000, rotated: true},
+		{name: "size rotation off", policy: rotationPolicy{maxArchives: 5}, size: 1 << 20},
+		{name: "older than the age limit", policy: rotationPolicy{maxAge: time.Hour, maxArchives: 5}, openedAt: now.Add(-2 * time.Hour), rotated: true},
+		{name: "younger than the age limit", policy: rotationPolicy{maxAge: time.Hour, maxArchives: 5}, openedAt: now.Add(-time.Minute)},
+		{name: "empty file never ages", policy: rotationPolicy{maxAge: time.Hour, maxArchives: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var m sLogger
+			if m.InitFileLogs(dir) == nil {
+				t.Fatal("could not open logs.csv")
+			}
+			defer func() { m.LogFile.Close() }()
+
+			// Grow logs.csv through another handle, as a hook process would
+			header := int64(len(strings.Join(CSVHeader, ",")) + 1)
+			size := max(tt.size, header)
+			hook, err := os.OpenFile(filepath.Join(dir, Logs), os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = hook.WriteString(strings.Repeat("x", int(size-header)))
+			hook.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m.mu.Lock()
+			m.rotation = tt.policy
+			m.openedAt = tt.openedAt
+			m.rotateIfDue(now)
+			openedAt := m.openedAt
+			m.mu.Unlock()
+
+			info, err := os.Stat(filepath.Join(dir, Logs))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.rotated {
+				if info.Size() != size {
+					t.Errorf("logs.csv is %d bytes, want it unchanged at %d", info.Size(), size)
+				}
+				if archives, _ := filepath.Glob(filepath.Join(dir, archivePrefix+"*")); len(archives) != 0 {
+					t.Errorf("archived %v, want no rotation", archives)
+				}
+				return
+			}
+
+			if info.Size() != header || !openedAt.IsZero() {
+				t.Errorf("after rotation logs.csv is %d bytes, openedAt = %v, want just the header", info.Size(), openedAt)
+			}
+
+			// The archive is compressed in the background
+			want := filepath.Join(dir, archivePrefix+now.UTC().Format(archiveTimeFormat)+archiveSuffix)
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				pending, _ := filepath.Glob(filepath.Join(dir, archivePrefix+"*.csv"))
+				if _, err := os.Stat(want); err == nil && len(pending) == 0 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("%s was not written", filepath.Base(want))
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestPruneArchives(t *testing.T) {
+	now := time.Now().UTC()
+	name := func(age time.Duration) string {
+		return archivePrefix + now.Add(-age).Format(archiveTimeFormat) + archiveSuffix
+	}
+	day := 24 * time.Hour
+	ages := []time.Duration{time.Hour, day, 3 * day, 10 * day, 40 * day}
+
+	tests := []struct {
+		name   string
+		policy rotationPolicy
+		keep   []time.Duration
+	}{
+		{name: "under the count", policy: rotationPolicy{maxArchives: 10}, keep: ages},
+		{name: "over the count", policy: rotationPolicy{maxArchives: 2}, keep: ages[:2]},
+		{name: "retention", policy: rotationPolicy{maxArchives: 10, retention: 7 * day}, keep: ages[:3]},
+		{name: "count and retention", policy: rotationPolicy{maxArchives: 1, retention: 7 * day}, keep: ages[:1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, age := range ages {
+				if err := os.WriteFile(filepath.Join(dir, name(age)), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Files that aren't archives are left alone
+			if err := os.WriteFile(filepath.Join(dir, Logs), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			pruneArchives(dir, tt.policy)
+
+			got, err := archiveNames(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, age := range tt.keep {
+				want = append(want, name(age))
+			}
+			sort.Sort(sort.Reverse(sort.StringSlice(want)))
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("kept %v, want %v", got, want)
+			}
+			if _, err := os.Stat(filepath.Join(dir, Logs)); err != nil {
+				t.Errorf("logs.csv was removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestArchiveTime(t *testing.T) {
+	tests := []struct {
+		name string
+		want time.Time
+	}{
+		{name: "logs-20240501-101500.250.csv.gz", want: time.Date(2024, 5, 1, 10, 15, 0, 250e6, time.UTC)},
+		{name: "logs-garbage.csv.gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := archiveTime(tt.name); !got.Equal(tt.want) {
+				t.Errorf("archiveTime(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// SinkConfig selects a log destination in config.json
+type SinkConfig struct {
+	// Type is one of "csv", "json", "syslog" or "console"
+	Type string `json:"type"`
+	// MinLevel is the lowest level written to the sink (INFO, WARN or ERROR)
+	MinLevel string `json:"min_level,omitempty"`
+	// Path of the json sink's file, relative to the .gitport directory
+	Path string `json:"path,omitempty"`
+	// Network ("udp" or "unix") and Address of the syslog sink
+	Network string `json:"network,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// logEntry is a single log call handed to the sinks
+type logEntry struct {
+	time    time.Time
+	level   string
+	msg     string
+	keyvals []interface{}
+}
+
+// sink is a log destination in addition to logs.csv
+type sink interface {
+	write(e logEntry) error
+	close() error
+}
+
+// configuredSink pairs a sink with the lowest level it accepts
+type configuredSink struct {
+	sink
+	kind     string
+	minLevel int
+}
+
+var levelRanks = map[string]int{"INFO": 0, "WARN": 1, "ERROR": 2}
+
+// levelRank orders levels from least to most severe, defaulting to INFO
+func levelRank(level string) int {
+	return levelRanks[strings.ToUpper(level)]
+}
+
+// ConfigureSinks replaces the extra log sinks with the ones in cfgs. logs.csv
+// is always written since the TUI reads it, but its level can be set with a
+// "csv" entry.
+func (m *sLogger) ConfigureSinks(cfgs []SinkConfig) {
+	var sinks []configuredSink
+	var failures []interface{}
+	csvLevel := 0
+
+	for _, cfg := range cfgs {
+		kind := strings.ToLower(cfg.Type)
+		if kind == "csv" {
+			csvLevel = levelRank(cfg.MinLevel)
+			continue
+		}
+
+		s, err := newSink(kind, cfg)
+		if err != nil {
+			failures = append(failures, cfg.Type, err)
+			continue
+		}
+		sinks = append(sinks, configuredSink{sink: s, kind: kind, minLevel: levelRank(cfg.MinLevel)})
+	}
+
+	m.mu.Lock()
+	old := m.sinks
+	m.sinks = sinks
+	m.csvLevel = csvLevel
+	m.mu.Unlock()
+
+	for _, s := range old {
+		s.close()
+	}
+
+	// Reported once the new sinks are in place so it reaches the working ones
+	for i := 0; i+1 < len(failures); i += 2 {
+		m.Error("Could not open log sink", "type", failures[i], "error", failures[i+1])
+	}
+}
+
+// CloseSinks closes every extra log sink
+func (m *sLogger) CloseSinks() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sinks {
+		s.close()
+	}
+	m.sinks = nil
+}
+
+// newSink opens the sink described by cfg
+func newSink(kind string, cfg SinkConfig) (sink, error) {
+	switch kind {
+	case "json":
+		path := cfg.Path
+		if path == "" {
+			path = "logs.jsonl"
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(ConfigDir, path)
+		}
+		return newJSONSink(path)
+	case "syslog":
+		return newSyslogSink(cfg.Network, cfg.Address)
+	case "console":
+		return newConsoleSink(os.Stderr), nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
+
+// keyvalString renders a key or value the same way across sinks
+func keyvalString(v interface{}) string {
+	return fmt.Sprintf("%v", v)
+}
+
+// jsonSink writes newline-delimited JSON objects to a file
+type jsonSink struct {
+	file *os.File
+}
+
+func newJSONSink(path string) (*jsonSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonSink{file: file}, nil
+}
+
+func (s *jsonSink) write(e logEntry) error {
+	line, err := json.Marshal(e.record())
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *jsonSink) close() error {
+	return s.file.Close()
+}
+
+// syslogSink sends RFC 5424 messages over UDP or a unix socket
+type syslogSink struct {
+	network  string
+	address  string
+	conn     net.Conn
+	stream   bool
+	hostname string
+}
+
+func newSyslogSink(network, address string) (*syslogSink, error) {
+	switch network {
+	case "", "udp":
+		network = "udp"
+	case "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+	if address == "" {
+		if network == "udp" {
+			address = "127.0.0.1:514"
+		} else {
+			address = "/dev/log"
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	s := &syslogSink{network: network, address: address, hostname: hostname}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// dial connects to the syslog daemon, preferring datagrams for unix sockets
+func (s *syslogSink) dial() error {
+	var err error
+	if s.network == "unix" {
+		if s.conn, err = net.Dial("unixgram", s.address); err == nil {
+			s.stream = false
+			return nil
+		}
+	}
+	s.conn, err = net.Dial(s.network, s.address)
+	s.stream = s.network == "unix"
+	return err
+}
+
+// syslogSeverities maps levels to RFC 5424 severities
+var syslogSeverities = map[string]int{"ERROR": 3, "WARN": 4, "INFO": 6}
+
+// syslogEscaper escapes structured data parameter values
+var syslogEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+func (s *syslogSink) write(e logEntry) error {
+	const facilityUser = 1
+	pri := facilityUser*8 + syslogSeverities[e.level]
+
+	data := "-"
+	if len(e.keyvals) > 1 {
+		var sd strings.Builder
+		sd.WriteString("[gitport@32473")
+		for i := 0; i+1 < len(e.keyvals); i += 2 {
+			fmt.Fprintf(&sd, ` %s="%s"`, sdName(keyvalString(e.keyvals[i])), syslogEscaper.Replace(keyvalString(e.keyvals[i+1])))
+		}
+		sd.WriteString("]")
+		data = sd.String()
+	}
+
+	msg := fmt.Sprintf("<%d>1 %s %s gitport %d - %s %s",
+		pri, e.time.Format(time.RFC3339Nano), s.hostname, os.Getpid(), data, e.msg)
+	if s.stream {
+		// Stream sockets need each message framed by a newline
+		msg += "\n"
+	}
+
+	if _, err := io.WriteString(s.conn, msg); err != nil {
+		// The daemon may have restarted, try once more on a fresh connection
+		s.conn.Close()
+		if err := s.dial(); err != nil {
+			return err
+		}
+		_, err = io.WriteString(s.conn, msg)
+		return err
+	}
+	return nil
+}
+
+// sdName strips characters RFC 5424 forbids in parameter names
+func sdName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= 32 || r >= 127 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func (s *syslogSink) close() error {
+	return s.conn.Close()
+}
+
+// consoleSink prints coloured log lines to the host terminal
+type consoleSink struct {
+	logger *log.Logger
+}
+
+func newConsoleSink(w io.Writer) *consoleSink {
+	return &consoleSink{logger: log.NewWithOptions(w, log.Options{
+		ReportTimestamp: true,
+		TimeFormat:      "2006-01-02 15:04:05",
+		Level:           log.InfoLevel,
+	})}
+}
+
+func (s *consoleSink) write(e logEntry) error {
+	switch e.level {
+	case "ERROR":
+		s.logger.Error(e.msg, e.keyvals...)
+	case "WARN":
+		s.logger.Warn(e.msg, e.keyvals...)
+	default:
+		s.logger.Info(e.msg, e.keyvals...)
+	}
+	return nil
+}
+
+func (s *consoleSink) close() error {
+	return nil
+}
@@ -0,0 +1,45 @@
+package logger
+
+import "sync"
+
+// subscriberBuffer is how many records a slow subscriber can fall behind
+// before new ones are dropped for it
+const subscriberBuffer = 256
+
+var (
+	subscribers   = make(map[chan Record]struct{})
+	subscribersMu sync.Mutex
+)
+
+// Subscribe streams every record written to logs.csv by this process. The
+// returned function ends the subscription and closes the channel.
+func Subscribe() (<-chan Record, func()) {
+	ch := make(chan Record, subscriberBuffer)
+
+	subscribersMu.Lock()
+	subscribers[ch] = struct{}{}
+	subscribersMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			delete(subscribers, ch)
+			subscribersMu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish hands a record to every subscriber without blocking the logger
+func publish(r Record) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+
+	for ch := range subscribers {
+		select {
+		case ch <- r:
+		default:
+		}
+	}
+}
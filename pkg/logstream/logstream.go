@@ -0,0 +1,253 @@
+package logstream
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+
+	"github.com/nim-sam/gitport/pkg/auth"
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// Command is the SSH exec command served by the middleware
+const Command = "logs"
+
+// levels in order of severity, for --level
+var levels = []string{"INFO", "WARN", "ERROR"}
+
+// options are the flags of the logs command
+type options struct {
+	since  string
+	level  string
+	format string
+	follow bool
+	query  string
+}
+
+// parseOptions reads the logs command line, reporting usage errors to w
+func parseOptions(args []string, w io.Writer) (options, error) {
+	var opts options
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(w)
+	fs.StringVar(&opts.since, "since", "", "only show records newer than a duration (1h), date or RFC 3339 time")
+	fs.StringVar(&opts.level, "level", "", "lowest level to show (info, warn or error)")
+	fs.StringVar(&opts.format, "format", "text", "output format (text, json or csv)")
+	fs.BoolVar(&opts.follow, "follow", false, "keep streaming new records")
+	fs.Usage = func() {
+		fmt.Fprintln(w, "Usage: logs [--since 1h] [--level warn] [--format text|json|csv] [--follow] [query]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	opts.query = strings.Join(fs.Args(), " ")
+
+	switch opts.format {
+	case "text", "json", "csv":
+	default:
+		return opts, fmt.Errorf("unknown format %q", opts.format)
+	}
+	return opts, nil
+}
+
+// buildQuery combines the flags with the free-form query, using the Logs tab syntax
+func (o options) buildQuery() (logger.Query, error) {
+	terms := []string{o.query}
+	if o.since != "" {
+		terms = append(terms, "since:"+o.since)
+	}
+	if o.level != "" {
+		lowest := -1
+		for i, level := range levels {
+			if strings.EqualFold(level, o.level) {
+				lowest = i
+			}
+		}
+		if lowest < 0 {
+			return logger.Query{}, fmt.Errorf("unknown level %q", o.level)
+		}
+		terms = append(terms, "level:"+strings.Join(levels[lowest:], ","))
+	}
+	return logger.ParseQuery(strings.Join(terms, " "))
+}
+
+// writeRecord prints a record in the requested format
+func writeRecord(w io.Writer, format string, r logger.Record) error {
+	switch format {
+	case "json":
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(line, '\n'))
+		return err
+	case "csv":
+		line, err := logger.EncodeCSV(r)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(line)
+		return err
+	default:
+		line := fmt.Sprintf("%s %-5s %s", r.Time.Local().Format("2006-01-02 15:04:05"), r.Level, r.Message)
+		if fields := r.FieldString(); fields != "" {
+			line += "  " + fields
+		}
+		_, err := fmt.Fprintln(w, line)
+		return err
+	}
+}
+
+/*
+ * Middleware serves the "logs" SSH command, which prints the server logs
+ * and can keep streaming them. Only admins and auditors may run it.
+ */
+func Middleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			cmd := sess.Command()
+			if len(cmd) == 0 || cmd[0] != Command {
+				next(sess)
+				return
+			}
+
+			pubKey := sess.PublicKey()
+			if pubKey == nil {
+				wish.Fatalln(sess, "Authentication required")
+				return
+			}
+
+			userKey := pubKey.Type() + " " + base64.StdEncoding.EncodeToString(pubKey.Marshal())
+			user, exists := auth.GetUserByKey(userKey)
+			if !exists || (user.Perm != "admin" && user.Perm != "auditor") {
+				logger.Logger.Warn("Log access denied", "user", sess.User(), "remote", sess.RemoteAddr().String())
+				wish.Fatalln(sess, "Access denied: admin or auditor permission required to read logs")
+				return
+			}
+
+			opts, err := parseOptions(cmd[1:], sess.Stderr())
+			if err == flag.ErrHelp {
+				sess.Exit(0)
+				return
+			}
+			if err != nil {
+				wish.Fatalln(sess, err)
+				return
+			}
+
+			query, err := opts.buildQuery()
+			if err != nil {
+				wish.Fatalln(sess, "Invalid query:", err)
+				return
+			}
+
+			logger.Logger.Info("Log export started", "user", user.Name, "remote", sess.RemoteAddr().String(), "follow", opts.follow)
+
+			if err := stream(sess, opts, query); err != nil {
+				wish.Fatalln(sess, err)
+				return
+			}
+			sess.Exit(0)
+		}
+	}
+}
+
+// stream writes the matching history and, when following, every new record
+// until the client disconnects
+func stream(sess ssh.Session, opts options, query logger.Query) error {
+	if opts.format == "csv" {
+		fmt.Fprintln(sess, strings.Join(logger.CSVHeader, ","))
+	}
+
+	// Subscribing first means nothing logged while the history is read is
+	// missed; what the history already has is skipped when following
+	var live <-chan logger.Record
+	if opts.follow {
+		var unsubscribe func()
+		live, unsubscribe = logger.Subscribe()
+		defer unsubscribe()
+	}
+
+	var seen replayed
+	var writeErr error
+	err := logger.ReadLogsSince(query.Since, func(r logger.Record) error {
+		seen.add(r)
+		if !query.Match(r) {
+			return nil
+		}
+		writeErr = writeRecord(sess, opts.format, r)
+		return writeErr
+	})
+	if writeErr != nil {
+		return nil // The client went away
+	}
+	if err != nil {
+		return fmt.Errorf("could not read logs: %w", err)
+	}
+
+	if !opts.follow {
+		return nil
+	}
+
+	for {
+		select {
+		case <-sess.Context().Done():
+			return nil
+		case r := <-live:
+			if seen.has(r) || !query.Match(r) {
+				continue
+			}
+			if err := writeRecord(sess, opts.format, r); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// replayed tracks the end of the history written by stream. logs.csv keeps
+// times to the second, so the records of the last second are counted to tell
+// them apart from new ones logged in the same second.
+type replayed struct {
+	last    time.Time
+	records map[string]int
+}
+
+// add records a record of the history
+func (p *replayed) add(r logger.Record) {
+	t := r.Time.Truncate(time.Second)
+	if !t.Equal(p.last) {
+		p.last = t
+		p.records = make(map[string]int)
+	}
+	p.records[replayKey(r)]++
+}
+
+// has reports whether a live record was already part of the history
+func (p *replayed) has(r logger.Record) bool {
+	t := r.Time.Truncate(time.Second)
+	if t.Before(p.last) {
+		return true
+	}
+	if !t.Equal(p.last) {
+		return false
+	}
+	key := replayKey(r)
+	if p.records[key] == 0 {
+		return false
+	}
+	p.records[key]--
+	return true
+}
+
+// replayKey identifies a record within a second
+func replayKey(r logger.Record) string {
+	return r.Level + "\x00" + r.Message + "\x00" + r.FieldString()
+}
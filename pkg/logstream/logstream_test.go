@@ -0,0 +1,43 @@
+package logstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+func TestReplayedHas(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	record := func(offset time.Duration, msg string) logger.Record {
+		return logger.Record{Time: base.Add(offset), Level: "INFO", Message: msg}
+	}
+
+	// The history as read back from logs.csv, to the second
+	var seen replayed
+	seen.add(record(-time.Second, "Push"))
+	seen.add(record(0, "Auth failed"))
+	seen.add(record(0, "Auth failed"))
+	seen.add(record(0, "Log export started"))
+
+	// Live records carry their full time
+	tests := []struct {
+		name   string
+		record logger.Record
+		want   bool
+	}{
+		{name: "older than the history", record: record(-time.Second+time.Millisecond, "Push"), want: true},
+		{name: "in the last second", record: record(300*time.Millisecond, "Log export started"), want: true},
+		{name: "repeated in the last second", record: record(400*time.Millisecond, "Auth failed"), want: true},
+		{name: "repeated once more", record: record(500*time.Millisecond, "Auth failed"), want: true},
+		{name: "more repeats than the history", record: record(600*time.Millisecond, "Auth failed"), want: false},
+		{name: "new in the last second", record: record(700*time.Millisecond, "Push"), want: false},
+		{name: "after the history", record: record(time.Second, "Log export started"), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := seen.has(tt.record); got != tt.want {
+			t.Errorf("%s: has = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
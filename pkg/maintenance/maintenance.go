@@ -0,0 +1,303 @@
+package maintenance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+const (
+	State = "maintenance.json"
+
+	defaultInterval = 24 * time.Hour
+	maxHistory      = 30
+)
+
+// StepResult holds the outcome of a single maintenance command
+type StepResult struct {
+	Name     string        `json:"name"`
+	OK       bool          `json:"ok"`
+	Output   string        `json:"output,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Result holds the outcome of a full maintenance run
+type Result struct {
+	Time      time.Time    `json:"time"`
+	Steps     []StepResult `json:"steps"`
+	SizeBytes int64        `json:"size_bytes"`
+	Healthy   bool         `json:"healthy"`
+}
+
+// step is a git command run as part of maintenance
+type step struct {
+	name string
+	args []string
+}
+
+var steps = []step{
+	{"repack", []string{"repack", "-a", "-d", "-q"}},
+	{"gc", []string{"gc", "--quiet"}},
+	{"commit-graph", []string{"commit-graph", "write", "--reachable"}},
+	{"fsck", []string{"fsck", "--no-progress", "--no-dangling"}},
+}
+
+var (
+	repoPath string
+	history  []Result
+	running  bool
+	stateMu  sync.RWMutex
+	runMu    sync.Mutex
+	stop     chan struct{}
+)
+
+// Start loads the previous results and schedules periodic maintenance of the bare repo
+func Start(path string) {
+	repoPath = path
+	loadHistory()
+
+	value := logger.GetConfig().MaintenanceInterval
+	if _, _, err := parseInterval(value); err != nil {
+		logger.Logger.Warn("Invalid maintenance interval, using default", "value", value, "default", defaultInterval, "error", err)
+	}
+
+	stop = make(chan struct{})
+	go schedule(stop)
+}
+
+// Stop ends the periodic maintenance scheduler
+func Stop() {
+	if stop != nil {
+		close(stop)
+		stop = nil
+	}
+}
+
+// schedule runs maintenance every configured interval until stopped
+func schedule(done chan struct{}) {
+	for {
+		interval, enabled := Interval()
+		if !enabled {
+			// Check again later in case the config changes
+			interval = time.Hour
+		} else if last, ok := LastResult(); ok {
+			if wait := time.Until(last.Time.Add(interval)); wait < interval {
+				interval = wait
+			}
+		}
+		if interval < time.Minute {
+			interval = time.Minute
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(interval):
+			if _, enabled := Interval(); enabled {
+				Run()
+			}
+		}
+	}
+}
+
+// Interval returns the configured maintenance interval and whether it is
+// enabled. An invalid interval falls back to the default, which Start warns about.
+func Interval() (time.Duration, bool) {
+	interval, enabled, _ := parseInterval(logger.GetConfig().MaintenanceInterval)
+	return interval, enabled
+}
+
+// parseInterval reads a maintenance_interval value, returning the default
+// along with the error when it is invalid
+func parseInterval(value string) (time.Duration, bool, error) {
+	switch value = strings.TrimSpace(value); value {
+	case "":
+		return defaultInterval, true, nil
+	case "off", "0":
+		return 0, false, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err == nil && interval <= 0 {
+		err = fmt.Errorf("interval must be positive")
+	}
+	if err != nil {
+		return defaultInterval, true, err
+	}
+	return interval, true, nil
+}
+
+// Run performs a full maintenance pass on the repository and records its result.
+// Concurrent calls wait for the pass in progress to finish.
+func Run() Result {
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	stateMu.Lock()
+	running = true
+	stateMu.Unlock()
+
+	logger.Logger.Info("Repository maintenance started", "repo", repoPath)
+
+	result := Result{Time: time.Now(), Healthy: true}
+	for _, s := range steps {
+		res := runStep(s)
+		result.Steps = append(result.Steps, res)
+
+		if !res.OK {
+			if s.name == "fsck" {
+				result.Healthy = false
+				logger.Logger.Error("Repository integrity check failed", "repo", repoPath, "output", res.Output)
+			} else {
+				logger.Logger.Warn("Maintenance step failed", "step", s.name, "output", res.Output)
+			}
+		}
+	}
+
+	size, err := RepoSize(repoPath)
+	if err != nil {
+		logger.Logger.Warn("Could not measure repository size", "error", err)
+	}
+	result.SizeBytes = size
+
+	stateMu.Lock()
+	history = append(history, result)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	running = false
+	stateMu.Unlock()
+
+	saveHistory()
+
+	logger.Logger.Info("Repository maintenance finished", "healthy", result.Healthy, "size", FormatSize(size))
+	return result
+}
+
+// runStep runs a single git maintenance command inside the repository
+func runStep(s step) StepResult {
+	var out bytes.Buffer
+	cmd := exec.Command("git", s.args...)
+	cmd.Dir = repoPath
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	err := cmd.Run()
+
+	return StepResult{
+		Name:     s.name,
+		OK:       err == nil,
+		Output:   strings.TrimSpace(out.String()),
+		Duration: time.Since(start),
+	}
+}
+
+// RepoSize returns the on-disk size of the repository's object store,
+// including the Git LFS objects kept in its .gitport directory
+func RepoSize(path string) (int64, error) {
+	size, err := DirSize(filepath.Join(path, "objects"))
+	if err != nil {
+		return size, err
+	}
+	lfs, err := DirSize(filepath.Join(path, ".gitport", "lfs"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return size, err
+	}
+	return size + lfs, nil
+}
+
+// DirSize returns the total size of the files under dir
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// IsRunning reports whether a maintenance pass is in progress
+func IsRunning() bool {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return running
+}
+
+// LastResult returns the most recent maintenance result, if any
+func LastResult() (Result, bool) {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	if len(history) == 0 {
+		return Result{}, false
+	}
+	return history[len(history)-1], true
+}
+
+// History returns a copy of the recorded maintenance results, oldest first
+func History() []Result {
+	stateMu.RLock()
+	defer stateMu.RUnlock()
+	return append([]Result(nil), history...)
+}
+
+// loadHistory reads previous maintenance results from the .gitport directory
+func loadHistory() {
+	data, err := os.ReadFile(filepath.Join(logger.ConfigDir, State))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Logger.Warn("Could not read maintenance history", "file", State, "error", err)
+		}
+		return
+	}
+
+	var loaded []Result
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		logger.Logger.Warn("Could not parse maintenance history", "file", State, "error", err)
+		return
+	}
+
+	stateMu.Lock()
+	history = loaded
+	stateMu.Unlock()
+}
+
+// saveHistory writes the maintenance results to the .gitport directory
+func saveHistory() {
+	if err := logger.WriteJSONFile(State, History()); err != nil {
+		logger.Logger.Error("Failed to save maintenance history", "error", err)
+	}
+}
+
+// FormatSize renders a byte count in a human readable unit
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
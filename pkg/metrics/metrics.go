@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// Server metrics exposed on the metrics endpoint
+var (
+	SSHSessions      = NewCounter("gitport_ssh_sessions_total", "SSH sessions opened, by session type.", "type")
+	ActiveSessions   = NewGauge("gitport_ssh_sessions_active", "SSH sessions currently open.")
+	SessionDuration  = NewHistogram("gitport_ssh_session_duration_seconds", "Duration of SSH sessions, by session type.", DefBuckets, "type")
+	BytesTransferred = NewCounter("gitport_ssh_bytes_total", "Bytes transferred over SSH sessions, by direction.", "direction")
+	AuthAttempts     = NewCounter("gitport_auth_attempts_total", "Public key authentication attempts, by result.", "result")
+	Fetches          = NewCounter("gitport_fetches_total", "Successful fetches and clones, by repository.", "repo")
+	Pushes           = NewCounter("gitport_pushes_total", "Successful pushes, by repository.", "repo")
+	TUISessions      = NewCounter("gitport_tui_sessions_total", "Admin TUI sessions started.")
+	GitDuration      = NewHistogram("gitport_git_duration_seconds", "Duration of git transfers, by service. Pushes include the pre-receive hook.", DefBuckets, "service")
+	WatcherReloads   = NewCounter("gitport_file_watcher_reloads_total", "Files reloaded after an external change, by file.", "file")
+)
+
+// SessionType classifies an SSH session by the command it runs
+func SessionType(command []string) string {
+	switch {
+	case len(command) == 0:
+		return "tui"
+	case strings.HasPrefix(command[0], "git-lfs-"):
+		return "lfs"
+	case strings.HasPrefix(command[0], "git-"):
+		return "git"
+	case command[0] == "logs":
+		return "logs"
+	default:
+		return "other"
+	}
+}
+
+// ObserveSince records the time elapsed since start into a histogram
+func ObserveSince(h *Histogram, start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// countingSession counts the bytes read from and written to a session
+type countingSession struct {
+	ssh.Session
+}
+
+func (s countingSession) Read(p []byte) (int, error) {
+	n, err := s.Session.Read(p)
+	BytesTransferred.Add(float64(n), "in")
+	return n, err
+}
+
+func (s countingSession) Write(p []byte) (int, error) {
+	n, err := s.Session.Write(p)
+	BytesTransferred.Add(float64(n), "out")
+	return n, err
+}
+
+/*
+ * Middleware records session counts, durations and bytes transferred.
+ * It should wrap every other middleware so all sessions are measured.
+ */
+func Middleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			sessionType := SessionType(sess.Command())
+			SSHSessions.Inc(sessionType)
+			ActiveSessions.Inc()
+			start := time.Now()
+
+			defer func() {
+				ActiveSessions.Dec()
+				ObserveSince(SessionDuration, start, sessionType)
+				if sessionType == "git" {
+					ObserveSince(GitDuration, start, sess.Command()[0])
+				}
+			}()
+
+			next(countingSession{sess})
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package releases
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// Notes is the directory of the .gitport directory holding one Markdown
+// file of release notes per tag
+const Notes = "releases"
+
+// Release is a tag of the repository
+type Release struct {
+	Tag    string
+	Commit string
+	// Annotated tags carry a message, tagger and date of their own;
+	// lightweight ones borrow the date of their commit
+	Annotated bool
+	Message   string
+	Tagger    string
+	Date      time.Time
+}
+
+// fieldSep separates the for-each-ref fields of a tag
+const fieldSep = "\x1f"
+
+var repoPath string
+
+// Init sets the bare repository whose tags are managed
+func Init(path string) {
+	repoPath = path
+}
+
+// git runs a git command in the repository, returning its trimmed output
+func git(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// List returns the tags of the repository, newest first
+func List() ([]Release, error) {
+	format := strings.Join([]string{
+		"%(refname:short)", "%(objecttype)", "%(*objectname)", "%(objectname)",
+		"%(taggername)", "%(taggerdate:unix)", "%(committerdate:unix)", "%(contents)",
+	}, fieldSep) + fieldSep + "\x1e"
+
+	out, err := git("for-each-ref", "--sort=-creatordate", "--format="+format, "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []Release
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), fieldSep)
+		if len(fields) < 8 {
+			continue
+		}
+
+		r := Release{Tag: fields[0], Annotated: fields[1] == "tag"}
+		if r.Annotated {
+			r.Commit = fields[2]
+			r.Tagger = fields[4]
+			r.Date = unixTime(fields[5])
+			r.Message = strings.TrimSpace(fields[7])
+		} else {
+			r.Commit = fields[3]
+			r.Date = unixTime(fields[6])
+		}
+		releases = append(releases, r)
+	}
+	return releases, nil
+}
+
+func unixTime(s string) time.Time {
+	var sec int64
+	if _, err := fmt.Sscan(s, &sec); err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// Changelog lists "sha subject" lines of the commits a tag adds on top of
+// the previous one, or of its whole history when previous is empty
+func Changelog(tag, previous string) ([]string, error) {
+	revs := "refs/tags/" + tag
+	if previous != "" {
+		revs = "refs/tags/" + previous + "..refs/tags/" + tag
+	}
+	out, err := git("log", "--format=%h %s", revs)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// notesPath is where the release notes of a tag are stored
+func notesPath(tag string) string {
+	return filepath.Join(logger.ConfigDir, Notes, tag+".md")
+}
+
+// ReadNotes returns the release notes of a tag, empty when it has none
+func ReadNotes(tag string) string {
+	data, err := os.ReadFile(notesPath(tag))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Create tags a commit, given as a hash or ref, with an annotated tag and
+// saves its release notes. by is the user creating it, recorded as tagger.
+func Create(tag, target, message, notes, by string) error {
+	if _, err := git("check-ref-format", "refs/tags/"+tag); err != nil {
+		return fmt.Errorf("%q is not a valid tag name", tag)
+	}
+	if _, err := git("rev-parse", "--verify", "--quiet", "refs/tags/"+tag); err == nil {
+		return fmt.Errorf("tag %s already exists", tag)
+	}
+	commit, err := git("rev-parse", "--verify", "--quiet", target+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("%q is not a commit", target)
+	}
+	if strings.TrimSpace(message) == "" {
+		message = tag
+	}
+
+	cmd := exec.Command("git", "tag", "-a", tag, commit, "-F", "-")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME="+by, "GIT_COMMITTER_EMAIL="+by+"@gitport")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not create tag: %s", strings.TrimSpace(string(out)))
+	}
+
+	if strings.TrimSpace(notes) != "" {
+		if err := os.MkdirAll(filepath.Dir(notesPath(tag)), 0755); err != nil {
+			return fmt.Errorf("tag created, but its notes could not be saved: %w", err)
+		}
+		if err := os.WriteFile(notesPath(tag), []byte(notes), 0644); err != nil {
+			return fmt.Errorf("tag created, but its notes could not be saved: %w", err)
+		}
+	}
+
+	logger.Logger.Info("Release created", "tag", tag, "commit", commit[:7], "user", by)
+	return nil
+}
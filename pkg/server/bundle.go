@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// locateBareRepo returns the bare repository and .gitport paths for the repo in cwd
+func locateBareRepo(cwd string) (string, string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", "", fmt.Errorf("couldn't find user config directory: %w", err)
+	}
+
+	barePath := filepath.Join(configDir, "gitport", filepath.Base(cwd)+".git")
+	gpConf := filepath.Join(barePath, gpConfig)
+
+	if _, err := os.Stat(gpConf); err != nil {
+		return "", "", fmt.Errorf("no GitPort server found for this repo. Run `gitport init` first")
+	}
+
+	return barePath, gpConf, nil
+}
+
+// runGitIn runs a git command inside dir and returns its combined output
+func runGitIn(dir string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
+
+// listRefs returns a map of every ref in the repository to the object it points to
+func listRefs(repoPath string) (map[string]string, error) {
+	out, err := runGitIn(repoPath, "for-each-ref", "--format=%(objectname) %(refname)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %s", out)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.Fields(line)
+		if len(parts) == 2 {
+			refs[parts[1]] = parts[0]
+		}
+	}
+	return refs, nil
+}
+
+// exportBundle creates a bundle at out holding the history reachable from
+// every ref of the repository, starting after since (a ref or a date)
+func exportBundle(repoPath, since, out string) error {
+	args := []string{"bundle", "create", out, "--all"}
+
+	if since != "" {
+		if sha, err := runGitIn(repoPath, "rev-parse", "--verify", "--quiet", since+"^{commit}"); err == nil {
+			args = append(args, "^"+sha)
+		} else {
+			args = append(args, "--since="+since)
+		}
+	}
+
+	if output, err := runGitIn(repoPath, args...); err != nil {
+		return fmt.Errorf("git bundle create failed: %s", output)
+	}
+	return nil
+}
+
+// importBundle verifies a bundle and pushes its branches and tags into the
+// repository. Refs are never force-updated or deleted, so non-fast-forward
+// updates are rejected just like a regular push. It returns the refs that changed.
+func importBundle(repoPath, file string) (map[string][2]string, error) {
+	if output, err := runGitIn(repoPath, "bundle", "verify", file); err != nil {
+		return nil, fmt.Errorf("bundle verification failed: %s", output)
+	}
+
+	// The bundle is fetched into a scratch repository borrowing the objects of
+	// the real one, so incremental bundles resolve, and pushed from there: the
+	// push goes through receive-pack and the repository's hooks like any other
+	staging, err := os.MkdirTemp("", "gitport-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging repository: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if output, err := runGitIn(staging, "init", "--bare", "--quiet"); err != nil {
+		return nil, fmt.Errorf("failed to create staging repository: %s", output)
+	}
+	alternates := filepath.Join(staging, "objects", "info", "alternates")
+	if err := os.WriteFile(alternates, []byte(filepath.Join(repoPath, "objects")+"\n"), 0644); err != nil {
+		return nil, fmt.Errorf("failed to create staging repository: %w", err)
+	}
+
+	refspecs := []string{"refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*"}
+	if output, err := runGitIn(staging, append([]string{"fetch", "--quiet", "--no-write-fetch-head", file}, refspecs...)...); err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %s", output)
+	}
+
+	before, err := listRefs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	output, pushErr := runGitIn(staging, append([]string{"push", "--quiet", repoPath}, refspecs...)...)
+
+	after, err := listRefs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string][2]string)
+	for ref, newSha := range after {
+		if oldSha := before[ref]; oldSha != newSha {
+			changed[ref] = [2]string{oldSha, newSha}
+		}
+	}
+
+	if pushErr != nil {
+		return changed, fmt.Errorf("some refs were rejected: %s", output)
+	}
+	return changed, nil
+}
+
+// initBundleLogs points the file logger at the repo's .gitport directory
+func initBundleLogs(gpConf string) *os.File {
+	logger.ConfigDir = gpConf
+	return logger.Logger.InitFileLogs(gpConf)
+}
+
+// BundleExport writes an incremental bundle of the bare repository for offline transfer
+func BundleExport(since, out string) {
+	cwd, _ := os.Getwd()
+
+	barePath, gpConf, err := locateBareRepo(cwd)
+	if err != nil {
+		log.Error("Failed to fetch bare repo", "error", err)
+		return
+	}
+
+	if logs := initBundleLogs(gpConf); logs != nil {
+		defer logs.Close()
+	}
+
+	if out == "" {
+		repoName := strings.TrimSuffix(filepath.Base(barePath), ".git")
+		out = fmt.Sprintf("%s-%s.bundle", repoName, time.Now().Format("20060102-150405"))
+	}
+	out, _ = filepath.Abs(out)
+
+	if err := exportBundle(barePath, since, out); err != nil {
+		logger.Logger.Error("Bundle export failed", "since", since, "error", err)
+		log.Error("Bundle export failed", "error", err)
+		return
+	}
+
+	logger.Logger.Info("Bundle exported", "file", out, "since", since)
+	log.Info("Bundle exported", "file", out)
+}
+
+// BundleImport verifies a bundle and applies its refs to the bare repository
+func BundleImport(file string) {
+	cwd, _ := os.Getwd()
+
+	barePath, gpConf, err := locateBareRepo(cwd)
+	if err != nil {
+		log.Error("Failed to fetch bare repo", "error", err)
+		return
+	}
+
+	if logs := initBundleLogs(gpConf); logs != nil {
+		defer logs.Close()
+	}
+
+	file, _ = filepath.Abs(file)
+	changed, err := importBundle(barePath, file)
+
+	for ref, shas := range changed {
+		logger.Logger.Info("Ref updated from bundle", "ref", ref, "old", shas[0], "new", shas[1], "file", file)
+		log.Info("Ref updated", "ref", ref, "old", shortSha(shas[0]), "new", shortSha(shas[1]))
+	}
+
+	if err != nil {
+		logger.Logger.Error("Bundle import failed", "file", file, "error", err)
+		log.Error("Bundle import failed", "error", err)
+		return
+	}
+
+	if len(changed) == 0 {
+		log.Info("Bundle imported, repository already up to date")
+	}
+	logger.Logger.Info("Bundle imported", "file", file, "refs_changed", len(changed))
+}
+
+// shortSha abbreviates an object id for display
+func shortSha(sha string) string {
+	if sha == "" {
+		return "(new)"
+	}
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+const (
+	hookMarker = "# Installed by GitPort"
+	zeroSha    = "0000000000000000000000000000000000000000"
+)
+
+// gitHooks lists the server-side git hooks that GitPort handles itself
+var gitHooks = []string{"pre-receive"}
+
+// refUpdate is a single ref change read from a receive hook's stdin
+type refUpdate struct {
+	old, new, ref string
+}
+
+// installHooks writes the GitPort hook scripts into the bare repository.
+// Existing hooks that were not installed by GitPort are left untouched.
+func installHooks(repoPath string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not locate gitport executable: %w", err)
+	}
+
+	hooksDir := filepath.Join(repoPath, "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range gitHooks {
+		hookPath := filepath.Join(hooksDir, name)
+
+		if existing, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(existing), hookMarker) {
+			logger.Logger.Warn("Custom git hook found, not replacing it", "hook", name)
+			continue
+		}
+
+		script := fmt.Sprintf("#!/bin/sh\n%s, do not edit\nexec %q hook %s\n", hookMarker, exe, name)
+		if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+			return fmt.Errorf("failed to write %s hook: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readRefUpdates parses the "<old> <new> <ref>" lines git passes to receive hooks
+func readRefUpdates(r io.Reader) []refUpdate {
+	var updates []refUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 3 {
+			updates = append(updates, refUpdate{old: parts[0], new: parts[1], ref: parts[2]})
+		}
+	}
+	return updates
+}
+
+// rejectPush prints a rejection message that git relays to the pushing client
+func rejectPush(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "GitPort: push rejected: "+format+"\n", args...)
+}
+
+// RunHook is executed by git inside the bare repository for each installed hook
+// and returns the exit code git expects
+func RunHook(name string) int {
+	repoPath, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "GitPort: could not locate repository:", err)
+		return 1
+	}
+
+	logger.ConfigDir = filepath.Join(repoPath, gpConfig)
+	if logs := logger.Logger.InitFileLogs(logger.ConfigDir); logs != nil {
+		defer logs.Close()
+	}
+
+	if err := logger.LoadConfig(); err != nil {
+		logger.Logger.Error("Hook could not load config", "hook", name, "error", err)
+	}
+
+	// Rejections reach the same sinks as the server's own records, except the
+	// console: git relays whatever a hook prints to the pushing client
+	var sinks []logger.SinkConfig
+	for _, s := range logger.GetConfig().LogSinks {
+		if !strings.EqualFold(s.Type, "console") {
+			sinks = append(sinks, s)
+		}
+	}
+	logger.Logger.ConfigureSinks(sinks)
+	defer logger.Logger.CloseSinks()
+
+	switch name {
+	case "pre-receive":
+		if err := checkQuotas(repoPath, readRefUpdates(os.Stdin)); err != nil {
+			rejectPush("%v", err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, "GitPort: unknown hook", name)
+		return 1
+	}
+}
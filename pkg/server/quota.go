@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+	"github.com/nim-sam/gitport/pkg/maintenance"
+)
+
+// quotas holds the storage limits in bytes, zero meaning unlimited
+type quotas struct {
+	push, blob, repo int64
+}
+
+// loadQuotas reads the storage limits from the server config
+func loadQuotas() quotas {
+	config := logger.GetConfig()
+	var q quotas
+
+	limits := []struct {
+		key   string
+		value string
+		dest  *int64
+	}{
+		{"max_push_size", config.MaxPushSize, &q.push},
+		{"max_blob_size", config.MaxBlobSize, &q.blob},
+		{"max_repo_size", config.MaxRepoSize, &q.repo},
+	}
+	for _, l := range limits {
+		size, err := logger.ParseSize(l.value)
+		if err != nil {
+			logger.Logger.Warn("Invalid quota in config, ignoring it", "key", l.key, "error", err)
+			continue
+		}
+		*l.dest = size
+	}
+	return q
+}
+
+// newObject is an object introduced by a push
+type newObject struct {
+	kind string
+	sha  string
+	size int64
+	path string
+}
+
+// checkQuotas rejects a push whose pack, blobs or resulting repository exceed
+// the configured limits. It runs in the pre-receive hook, while the pushed
+// objects are still quarantined.
+func checkQuotas(repoPath string, updates []refUpdate) error {
+	q := loadQuotas()
+	if q == (quotas{}) {
+		return nil
+	}
+
+	refs := make([]string, 0, len(updates))
+	for _, u := range updates {
+		refs = append(refs, u.ref)
+	}
+
+	reject := func(reason string, keyvals ...interface{}) error {
+		logger.Logger.Warn("Push rejected by quota", append([]interface{}{"reason", reason, "refs", strings.Join(refs, " ")}, keyvals...)...)
+		return fmt.Errorf("%s", reason)
+	}
+
+	if q.push > 0 {
+		if size := incomingSize(repoPath, updates); size > q.push {
+			return reject(fmt.Sprintf("push of %s exceeds the maximum push size of %s",
+				maintenance.FormatSize(size), maintenance.FormatSize(q.push)), "size", size)
+		}
+	}
+
+	if q.blob > 0 {
+		objects, err := newObjects(repoPath, updates)
+		if err != nil {
+			logger.Logger.Error("Could not inspect pushed objects", "error", err)
+			return fmt.Errorf("could not inspect pushed objects")
+		}
+		for _, obj := range objects {
+			if obj.kind == "blob" && obj.size > q.blob {
+				return reject(fmt.Sprintf("file %s (%s) exceeds the maximum file size of %s",
+					obj.path, maintenance.FormatSize(obj.size), maintenance.FormatSize(q.blob)), "path", obj.path, "size", obj.size)
+			}
+		}
+	}
+
+	if q.repo > 0 {
+		// The quarantine directory lives inside objects/, so this includes the push
+		size, err := maintenance.RepoSize(repoPath)
+		if err == nil && size > q.repo {
+			return reject(fmt.Sprintf("repository would grow to %s, over the maximum repository size of %s",
+				maintenance.FormatSize(size), maintenance.FormatSize(q.repo)), "size", size)
+		}
+	}
+
+	return nil
+}
+
+// incomingSize returns the size of the data received for this push
+func incomingSize(repoPath string, updates []refUpdate) int64 {
+	if quarantine := os.Getenv("GIT_QUARANTINE_PATH"); quarantine != "" {
+		if size, err := maintenance.DirSize(quarantine); err == nil {
+			return size
+		}
+	}
+
+	// Older git versions don't quarantine pushes, so fall back to the objects themselves
+	objects, err := newObjects(repoPath, updates)
+	if err != nil {
+		return 0
+	}
+	var size int64
+	for _, obj := range objects {
+		size += obj.size
+	}
+	return size
+}
+
+// newObjects lists the objects reachable from the pushed refs that the
+// repository didn't already have
+func newObjects(repoPath string, updates []refUpdate) ([]newObject, error) {
+	args := []string{"rev-list", "--objects"}
+	for _, u := range updates {
+		if u.new != zeroSha {
+			args = append(args, u.new)
+		}
+	}
+	if len(args) == 2 {
+		return nil, nil
+	}
+	args = append(args, "--not", "--all")
+
+	list, err := runGitIn(repoPath, args...)
+	if err != nil {
+		return nil, fmt.Errorf("rev-list failed: %s", list)
+	}
+	if list == "" {
+		return nil, nil
+	}
+
+	// Feed "<sha> <path>" lines to cat-file, which keeps the path as %(rest)
+	var out bytes.Buffer
+	cmd := exec.Command("git", "cat-file", "--batch-check=%(objecttype) %(objectname) %(objectsize) %(rest)")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(list + "\n")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cat-file failed: %w", err)
+	}
+
+	var objects []newObject
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), " ", 4)
+		if len(parts) < 3 {
+			continue
+		}
+		size, _ := strconv.ParseInt(parts[2], 10, 64)
+		obj := newObject{kind: parts[0], sha: parts[1], size: size}
+		if len(parts) == 4 {
+			obj.path = parts[3]
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
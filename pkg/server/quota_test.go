@@ -0,0 +1,112 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// gitIn runs a git command for a test, failing it on error
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)
+	out, err := runGitIn(dir, args...)
+	if err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+	return out
+}
+
+// setupPush creates a repository holding a commit that no ref points to yet,
+// as if it had just been received, and returns its git directory and the commit.
+// The commit adds big.bin, a blob of 4 KiB.
+func setupPush(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	gitIn(t, dir, "init", "--quiet", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "README")
+	gitIn(t, dir, "commit", "--quiet", "-m", "base")
+
+	gitIn(t, dir, "checkout", "--quiet", "-b", "incoming")
+	if err := os.WriteFile(filepath.Join(dir, "big.bin"), []byte(strings.Repeat("x", 4096)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, dir, "add", "big.bin")
+	gitIn(t, dir, "commit", "--quiet", "-m", "add big.bin")
+	sha := gitIn(t, dir, "rev-parse", "HEAD")
+	gitIn(t, dir, "checkout", "--quiet", "main")
+	gitIn(t, dir, "branch", "--quiet", "-D", "incoming")
+
+	return filepath.Join(dir, ".git"), sha
+}
+
+func TestCheckQuotas(t *testing.T) {
+	// Without a quarantine directory the push is measured from its objects
+	t.Setenv("GIT_QUARANTINE_PATH", "")
+	os.Unsetenv("GIT_QUARANTINE_PATH")
+	repoPath, sha := setupPush(t)
+	push := []refUpdate{{old: zeroSha, new: sha, ref: "refs/heads/incoming"}}
+	deletion := []refUpdate{{old: sha, new: zeroSha, ref: "refs/heads/incoming"}}
+
+	tests := []struct {
+		name    string
+		config  logger.ConfigData
+		updates []refUpdate
+		wantErr string
+	}{
+		{name: "no limits", updates: push},
+		{name: "push under the limit", config: logger.ConfigData{MaxPushSize: "1MB"}, updates: push},
+		{name: "push over the limit", config: logger.ConfigData{MaxPushSize: "4KB"}, updates: push, wantErr: "exceeds the maximum push size of 4.0 KiB"},
+		{name: "file at the limit", config: logger.ConfigData{MaxBlobSize: "4KB"}, updates: push},
+		{name: "file over the limit", config: logger.ConfigData{MaxBlobSize: "1KB"}, updates: push, wantErr: "file big.bin (4.0 KiB) exceeds the maximum file size of 1.0 KiB"},
+		{name: "deletion with a file limit", config: logger.ConfigData{MaxBlobSize: "1"}, updates: deletion},
+		{name: "repository under the limit", config: logger.ConfigData{MaxRepoSize: "1GB"}, updates: push},
+		{name: "repository over the limit", config: logger.ConfigData{MaxRepoSize: "1"}, updates: push, wantErr: "over the maximum repository size of 1 B"},
+		{name: "invalid limit is ignored", config: logger.ConfigData{MaxPushSize: "lots"}, updates: push},
+	}
+
+	saved := logger.GetConfig()
+	defer logger.SetConfig(saved)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger.SetConfig(tt.config)
+			err := checkQuotas(repoPath, tt.updates)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkQuotas = %v, want the push accepted", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkQuotas = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadRefUpdates(t *testing.T) {
+	input := zeroSha + " 1111111111111111111111111111111111111111 refs/heads/main\n" +
+		"malformed line\n" +
+		"2222222222222222222222222222222222222222 " + zeroSha + " refs/tags/v1\n"
+
+	got := readRefUpdates(strings.NewReader(input))
+	want := []refUpdate{
+		{old: zeroSha, new: "1111111111111111111111111111111111111111", ref: "refs/heads/main"},
+		{old: "2222222222222222222222222222222222222222", new: zeroSha, ref: "refs/tags/v1"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("readRefUpdates = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("update %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -27,7 +27,14 @@ import (
 	"github.com/charmbracelet/wish/git"
 
 	"github.com/nim-sam/gitport/pkg/auth"
+	"github.com/nim-sam/gitport/pkg/events"
+	"github.com/nim-sam/gitport/pkg/lfs"
 	"github.com/nim-sam/gitport/pkg/logger"
+	"github.com/nim-sam/gitport/pkg/logstream"
+	"github.com/nim-sam/gitport/pkg/maintenance"
+	"github.com/nim-sam/gitport/pkg/metrics"
+	"github.com/nim-sam/gitport/pkg/releases"
+	"github.com/nim-sam/gitport/pkg/sessions"
 	"github.com/nim-sam/gitport/pkg/tui"
 )
 
@@ -358,7 +365,7 @@ func (h Hook) AuthRepo(repo string, key ssh.PublicKey) git.AccessLevel {
 	}
 
 	switch user.Perm {
-	case "read":
+	case "read", "auditor":
 		return git.ReadOnlyAccess
 	case "write":
 		return git.ReadWriteAccess
@@ -369,14 +376,16 @@ func (h Hook) AuthRepo(repo string, key ssh.PublicKey) git.AccessLevel {
 	}
 }
 
-// Push logs push operations to the repository
+// Push counts push operations to the repository. The events middleware logs
+// who pushed what.
 func (h Hook) Push(repo string, key ssh.PublicKey) {
-	logger.Logger.Info("Push", "repo", repo)
+	metrics.Pushes.Inc(repo)
 }
 
-// Fetch logs fetch operations from the repository
+// Fetch counts fetch operations from the repository. The events middleware
+// logs who fetched and how.
 func (h Hook) Fetch(repo string, key ssh.PublicKey) {
-	logger.Logger.Info("Fetch", "repo", repo)
+	metrics.Fetches.Inc(repo)
 }
 
 // getLocalIP returns the local IP address of the machine
@@ -521,17 +530,27 @@ func (s *GpServer) initGitPortServer() error {
 	logger.ConfigDir = s.configDir
 
 	// Initialize file logging
+	// The log file stays open for as long as the server runs
 	logs := logger.Logger.InitFileLogs(s.configDir)
 	if logs == nil {
 		return fmt.Errorf("failed to initialize logs")
 	}
-	defer logs.Close()
+
+	// Load server configuration
+	if err := logger.LoadConfig(); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	logger.Logger.ConfigureSinks(logger.GetConfig().LogSinks)
+	logger.Logger.ConfigureRotation(logger.GetConfig())
 
 	// Initialize users and authentication
 	if err := auth.InitUsers(); err != nil {
 		return fmt.Errorf("failed to initialize users: %w", err)
 	}
 
+	// Keep a history of user changes for the Activity tab
+	auth.SetUserChangeCallback(events.RecordUserChange)
+
 	if err := auth.EnsureHostAdmin(); err != nil {
 		return fmt.Errorf("failed to ensure host admin: %w", err)
 	}
@@ -558,6 +577,11 @@ func (s GpServer) startGitPortServer() error {
 
 	hook := Hook{repoName: s.RepoName}
 	hostKeyPath := filepath.Join(s.configDir, ".ssh", "id_ed25519")
+	barePath := filepath.Join(s.RepoDir, s.RepoName)
+
+	if err := lfs.Init(barePath, hook.AuthRepo); err != nil {
+		logger.Logger.Error("Could not initialize Git LFS", "error", err)
+	}
 
 	server, err := wish.NewServer(
 		wish.WithAddress(net.JoinHostPort("0.0.0.0", s.Port)),
@@ -565,7 +589,12 @@ func (s GpServer) startGitPortServer() error {
 		wish.WithPublicKeyAuth(auth.AuthHandler),
 		wish.WithMiddleware(
 			git.Middleware(s.RepoDir, hook),
-			tui.Middleware("."),
+			events.Middleware(s.RepoDir),
+			lfs.Middleware(),
+			logstream.Middleware(),
+			tui.Middleware(barePath),
+			sessions.Middleware(),
+			metrics.Middleware(),
 		),
 	)
 
@@ -573,6 +602,22 @@ func (s GpServer) startGitPortServer() error {
 		return fmt.Errorf("could not create server: %w", err)
 	}
 
+	if addr := logger.GetConfig().LFSHTTPAddr; addr != "" {
+		_, lfsPort, _ := net.SplitHostPort(addr)
+		lfsServer := lfs.StartHTTP(addr, "http://"+net.JoinHostPort(localIP, lfsPort))
+		defer lfsServer.Close()
+	}
+
+	if addr := logger.GetConfig().MetricsAddr; addr != "" {
+		metricsServer, err := metrics.Serve(addr)
+		if err != nil {
+			logger.Logger.Error("Could not start metrics endpoint", "addr", addr, "error", err)
+		} else {
+			logger.Logger.Info("Metrics endpoint listening", "addr", addr)
+			defer metricsServer.Close()
+		}
+	}
+
 	// Start server with loading animation
 	showServerStartupAnimation(s.RepoName, fullURI)
 
@@ -763,6 +808,15 @@ func Start(port string) {
 	}
 	defer logger.CloseFileWatcher()
 
+	if err := installHooks(filepath.Join(repoDir, repoName)); err != nil {
+		logger.Logger.Warn("Could not install git hooks, push quotas are disabled", "error", err)
+	}
+
+	maintenance.Start(filepath.Join(repoDir, repoName))
+	defer maintenance.Stop()
+
+	releases.Init(filepath.Join(repoDir, repoName))
+
 	if err := server.startGitPortServer(); err != nil {
 		logger.Logger.Error("Server error", "error", err)
 	}
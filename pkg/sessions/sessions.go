@@ -0,0 +1,152 @@
+package sessions
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/nim-sam/gitport/pkg/auth"
+	"github.com/nim-sam/gitport/pkg/events"
+	"github.com/nim-sam/gitport/pkg/logger"
+	"github.com/nim-sam/gitport/pkg/metrics"
+)
+
+// Info describes an open SSH session
+type Info struct {
+	ID       int64
+	User     string
+	Remote   string
+	Type     string
+	Command  string
+	Start    time.Time
+	BytesIn  int64
+	BytesOut int64
+}
+
+// entry is a registered session
+type entry struct {
+	info        Info
+	fingerprint string
+	bytesIn     atomic.Int64
+	bytesOut    atomic.Int64
+	conn        io.Closer
+}
+
+var (
+	registry   = make(map[int64]*entry)
+	registryMu sync.Mutex
+	nextID     atomic.Int64
+)
+
+// idKey stores a session's registry ID in its context
+var idKey = &struct{ name string }{"gitport-session-id"}
+
+// ID returns the registry ID of the session a context belongs to
+func ID(ctx ssh.Context) (int64, bool) {
+	id, ok := ctx.Value(idKey).(int64)
+	return id, ok
+}
+
+// List returns the open sessions, oldest first
+func List() []Info {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	list := make([]Info, 0, len(registry))
+	for _, e := range registry {
+		info := e.info
+		info.BytesIn = e.bytesIn.Load()
+		info.BytesOut = e.bytesOut.Load()
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Terminate closes the connection of a session. by names who asked for it,
+// for the logs and the event history.
+func Terminate(id int64, by string) error {
+	registryMu.Lock()
+	e, ok := registry[id]
+	registryMu.Unlock()
+	if !ok {
+		return fmt.Errorf("session %d has already ended", id)
+	}
+	if e.conn == nil {
+		return fmt.Errorf("session %d has no connection to close", id)
+	}
+
+	logger.Logger.Warn("Session terminated", "user", e.info.User, "remote", e.info.Remote,
+		"type", e.info.Type, "command", e.info.Command, "by", by)
+	events.RecordTermination(e.info.User, e.fingerprint, e.info.Remote, e.info.Type, by)
+
+	return e.conn.Close()
+}
+
+// countingSession counts the bytes read from and written to a session
+type countingSession struct {
+	ssh.Session
+	e *entry
+}
+
+func (s countingSession) Read(p []byte) (int, error) {
+	n, err := s.Session.Read(p)
+	s.e.bytesIn.Add(int64(n))
+	return n, err
+}
+
+func (s countingSession) Write(p []byte) (int, error) {
+	n, err := s.Session.Write(p)
+	s.e.bytesOut.Add(int64(n))
+	return n, err
+}
+
+/*
+ * Middleware keeps a registry of open sessions for the Sessions tab, so
+ * admins can see who is connected and disconnect them.
+ */
+func Middleware() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sess ssh.Session) {
+			e := &entry{info: Info{
+				ID:      nextID.Add(1),
+				User:    sess.User(),
+				Remote:  sess.RemoteAddr().String(),
+				Type:    metrics.SessionType(sess.Command()),
+				Command: strings.Join(sess.Command(), " "),
+				Start:   time.Now(),
+			}}
+			if key := sess.PublicKey(); key != nil {
+				e.fingerprint = gossh.FingerprintSHA256(key)
+				if user, ok := auth.GetUserByKey(key.Type() + " " + base64.StdEncoding.EncodeToString(key.Marshal())); ok {
+					e.info.User = user.Name
+				}
+			}
+			if conn, ok := sess.Context().Value(ssh.ContextKeyConn).(io.Closer); ok {
+				e.conn = conn
+			}
+			sess.Context().SetValue(idKey, e.info.ID)
+
+			registryMu.Lock()
+			registry[e.info.ID] = e
+			registryMu.Unlock()
+
+			defer func() {
+				registryMu.Lock()
+				delete(registry, e.info.ID)
+				registryMu.Unlock()
+			}()
+
+			next(countingSession{Session: sess, e: e})
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// permissionText explains what each permission lets a user do
+var permissionText = map[string]string{
+	"read":    "Clone and fetch the repository, and browse it here",
+	"auditor": "Clone and fetch the repository, and browse it here",
+	"write":   "Clone, fetch and push branches and tags, and browse the repository here",
+	"admin":   "Full access, including users, logs and releases",
+}
+
+// accountModel shows the signed in user their own account
+type accountModel struct {
+	name        string
+	perm        string
+	keyType     string
+	fingerprint string
+	remote      string
+}
+
+func newAccount(name, perm, keyType, fingerprint, remote string) accountModel {
+	return accountModel{name: name, perm: perm, keyType: keyType, fingerprint: fingerprint, remote: remote}
+}
+
+func (m accountModel) Update(msg tea.Msg) (accountModel, tea.Cmd) {
+	return m, nil
+}
+
+func (m accountModel) View() string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070")).Width(16)
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	row := func(label, value string) string {
+		return labelStyle.Render(label) + valueStyle.Render(value)
+	}
+	rows := []string{
+		row("User", m.name),
+		row("Permission", m.perm),
+		row("Allows", permissionText[m.perm]),
+		row("Key", m.keyType+" "+m.fingerprint),
+		row("Connected from", m.remote),
+		"",
+		helpStyle.Render("Ask an admin to change your permission or add another key."),
+		"",
+		helpStyle.Render("[tab] Switch tab"),
+	}
+	return strings.Join(rows, "\n")
+}
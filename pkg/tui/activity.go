@@ -0,0 +1,315 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nim-sam/gitport/pkg/auth"
+	"github.com/nim-sam/gitport/pkg/events"
+)
+
+// maxActivity bounds how many events the feed keeps
+const maxActivity = 500
+
+// activityEventMsg carries an event recorded while the session is open
+type activityEventMsg events.Event
+
+type activityModel struct {
+	viewport viewport.Model
+	// feed holds the events shown in the tab, newest first
+	feed    []events.Event
+	updates <-chan events.Event
+
+	input   textinput.Model
+	editing bool
+	filter  string
+	err     string
+}
+
+// newActivity loads the event history and follows new events from updates
+func newActivity(updates <-chan events.Event) activityModel {
+	input := textinput.New()
+	input.Prompt = "Filter: "
+	input.Placeholder = "user:alice branch:main, or text matching either"
+	input.CharLimit = 100
+
+	m := activityModel{
+		viewport: viewport.New(0, 0),
+		updates:  updates,
+		input:    input,
+	}
+
+	history, err := events.Load(maxActivity, func(e events.Event) bool {
+		return e.Type != events.TypeFetch
+	})
+	if err != nil {
+		m.err = "Could not read activity: " + err.Error()
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		m.feed = append(m.feed, history[i])
+	}
+	return m
+}
+
+// waitForEvent delivers the next recorded event
+func waitForEvent(updates <-chan events.Event) tea.Cmd {
+	if updates == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		e, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return activityEventMsg(e)
+	}
+}
+
+func (m activityModel) Init() tea.Cmd {
+	return waitForEvent(m.updates)
+}
+
+func (m activityModel) Update(msg tea.Msg) (activityModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width
+		m.viewport.Height = max(msg.Height-2, 1) // Leave room for the filter bar and footer
+		m.input.Width = max(msg.Width-len(m.input.Prompt)-1, 1)
+		m.render()
+		return m, nil
+
+	case activityEventMsg:
+		if msg.Type != events.TypeFetch {
+			m.feed = append([]events.Event{events.Event(msg)}, m.feed...)
+			if len(m.feed) > maxActivity {
+				m.feed = m.feed[:maxActivity]
+			}
+			m.render()
+		}
+		return m, waitForEvent(m.updates)
+
+	case tea.KeyMsg:
+		if m.editing {
+			switch msg.String() {
+			case "enter", "esc":
+				if msg.String() == "enter" {
+					m.filter = strings.TrimSpace(m.input.Value())
+				}
+				m.editing = false
+				m.input.Blur()
+				m.render()
+				m.viewport.GotoTop()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "/":
+			m.editing = true
+			m.input.SetValue(m.filter)
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		case "esc":
+			m.filter = ""
+			m.render()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// matches reports whether an event passes the filter. user: and branch:
+// terms match the user and ref names, other words match either.
+func (m activityModel) matches(e events.Event) bool {
+	for _, term := range strings.Fields(strings.ToLower(m.filter)) {
+		key, value, found := strings.Cut(term, ":")
+		if !found {
+			key, value = "", term
+		}
+
+		userMatch := strings.Contains(strings.ToLower(e.User), value)
+		refMatch := false
+		for _, ref := range e.Refs {
+			if strings.Contains(strings.ToLower(ref.Name()), value) {
+				refMatch = true
+			}
+		}
+
+		switch key {
+		case "user":
+			if !userMatch {
+				return false
+			}
+		case "branch", "tag", "ref":
+			if !refMatch {
+				return false
+			}
+		default:
+			if !userMatch && !refMatch {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// render rebuilds the feed, grouped by day
+func (m *activityModel) render() {
+	dayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+
+	var b strings.Builder
+	if m.err != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Render(m.err) + "\n\n")
+	}
+
+	day := ""
+	shown := 0
+	for _, e := range m.feed {
+		if !m.matches(e) {
+			continue
+		}
+		shown++
+
+		if d := dayLabel(e.Time); d != day {
+			if day != "" {
+				b.WriteString("\n")
+			}
+			day = d
+			b.WriteString(dayStyle.Render(day) + "\n")
+		}
+
+		for i, line := range activityLines(e) {
+			prefix := "        "
+			if i == 0 {
+				prefix = dim.Render(e.Time.Local().Format("15:04")) + "   "
+			}
+			b.WriteString(prefix + line + "\n")
+		}
+	}
+
+	if shown == 0 {
+		if m.filter != "" {
+			b.WriteString(dim.Render("No activity matches the filter."))
+		} else {
+			b.WriteString(dim.Render("No activity yet. Pushes, new branches and tags, user changes and disconnects will appear here."))
+		}
+	}
+	m.viewport.SetContent(b.String())
+}
+
+// dayLabel names the day of a time relative to today
+func dayLabel(t time.Time) string {
+	t = t.Local()
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch {
+	case !t.Before(today):
+		return "Today"
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return "Yesterday"
+	default:
+		return t.Format("Monday, Jan 02 2006")
+	}
+}
+
+// activityLines describes an event, one line per ref or commit
+func activityLines(e events.Event) []string {
+	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true)
+	refStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6AB547"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")).Bold(true)
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+
+	user := userStyle.Render(e.User)
+
+	if e.Type == events.TypeSession {
+		return []string{fmt.Sprintf("%s was disconnected by %s %s", user, userStyle.Render(e.By),
+			dim.Render(fmt.Sprintf("(%s session from %s)", e.Kind, e.Remote)))}
+	}
+
+	if e.Type == events.TypeUser {
+		switch e.Kind {
+		case auth.UserEnrolled:
+			return []string{fmt.Sprintf("%s connected as a guest with %s access", user, e.Perm)}
+		case auth.UserAdded:
+			return []string{fmt.Sprintf("%s was added with %s access", user, e.Perm)}
+		case auth.UserRemoved:
+			return []string{fmt.Sprintf("%s was removed", user)}
+		default:
+			return []string{fmt.Sprintf("%s's permission changed from %s to %s", user, e.OldPerm, e.Perm)}
+		}
+	}
+
+	var lines []string
+	for _, ref := range e.Refs {
+		kind := "branch"
+		if ref.IsTag() {
+			kind = "tag"
+		}
+		name := refStyle.Render(ref.Name())
+
+		switch {
+		case ref.Deleted():
+			lines = append(lines, fmt.Sprintf("%s deleted %s %s", user, kind, name))
+			continue
+		case ref.Created():
+			line := fmt.Sprintf("%s created %s %s", user, kind, name)
+			if ref.Commits > 0 {
+				line += fmt.Sprintf(" with %s", plural(ref.Commits, "new commit"))
+			}
+			lines = append(lines, line)
+		default:
+			line := fmt.Sprintf("%s pushed %s to %s", user, plural(ref.Commits, "commit"), name)
+			if ref.Force {
+				line += " " + warnStyle.Render("(force-pushed)")
+			}
+			lines = append(lines, line)
+		}
+
+		for _, summary := range ref.Summaries {
+			lines = append(lines, "  "+dim.Render(summary))
+		}
+		if more := ref.Commits - len(ref.Summaries); more > 0 {
+			lines = append(lines, "  "+dim.Render(fmt.Sprintf("and %d more", more)))
+		}
+	}
+	return lines
+}
+
+// plural formats a count with a noun, adding an s unless it's one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// filterBar shows the filter being edited or the active one
+func (m activityModel) filterBar() string {
+	if m.editing {
+		return m.input.View()
+	}
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	if m.filter == "" {
+		return dim.Render("[/] Filter by user or branch")
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true).Render("Filter: ") + m.filter
+}
+
+func (m activityModel) View() string {
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	help := helpStyle.Render("[up/down] Scroll  [/] Filter  [esc] Clear filter  [tab] Switch tab")
+	return lipgloss.JoinVertical(lipgloss.Left, m.filterBar(), m.viewport.View(), help)
+}
@@ -0,0 +1,204 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// blameRequestMsg asks the Files tab to blame one of the files a commit changed
+type blameRequestMsg struct {
+	commit plumbing.Hash
+	files  []string
+}
+
+// blameMsg carries the result of blaming a file
+type blameMsg struct {
+	commit plumbing.Hash
+	path   string
+	result *git.BlameResult
+	err    error
+}
+
+// showCommitMsg asks the Commit History tab to show a single commit's diff
+type showCommitMsg struct {
+	hash plumbing.Hash
+}
+
+// blameModel annotates each line of a file with the commit that last changed it
+type blameModel struct {
+	commit  plumbing.Hash
+	path    string
+	lines   []*git.Line
+	code    []string // Highlighted text of the lines
+	loading bool
+	err     string
+	cursor  int
+	offset  int
+	width   int
+	height  int
+}
+
+// newBlame starts blaming a file as of a commit
+func newBlame(repoPath string, commit plumbing.Hash, path string, width, height int) (blameModel, tea.Cmd) {
+	m := blameModel{commit: commit, path: path, loading: true, width: width, height: height}
+	return m, runBlame(repoPath, commit, path)
+}
+
+// runBlame blames a file in the background, as it walks the file's history.
+// It opens its own repository, as the TUI keeps using its one meanwhile
+func runBlame(repoPath string, commit plumbing.Hash, path string) tea.Cmd {
+	return func() tea.Msg {
+		msg := blameMsg{commit: commit, path: path}
+		repo, err := git.PlainOpen(repoPath)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		c, err := repo.CommitObject(commit)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.result, msg.err = git.Blame(c, path)
+		return msg
+	}
+}
+
+// changedFiles lists the files a commit added or modified, which can be blamed at it
+func changedFiles(repo *git.Repository, hash plumbing.Hash) []string {
+	c, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil
+	}
+	var parentTree *object.Tree
+	if parent, err := c.Parent(0); err == nil {
+		parentTree, _ = parent.Tree()
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, change := range changes {
+		if change.To.Name != "" {
+			files = append(files, change.To.Name)
+		}
+	}
+	return files
+}
+
+func (m blameModel) Update(msg tea.Msg) (blameModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.scroll()
+
+	case blameMsg:
+		if msg.commit != m.commit || msg.path != m.path {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = "Could not blame " + m.path + ": " + msg.err.Error()
+			return m, nil
+		}
+		m.lines = msg.result.Lines
+		text := make([]string, len(m.lines))
+		for i, line := range m.lines {
+			text[i] = line.Text
+		}
+		m.code = highlightLines(m.path, strings.Join(text, "\n"))
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			m.cursor--
+		case "down", "j":
+			m.cursor++
+		case "pgup":
+			m.cursor -= m.height
+		case "pgdown":
+			m.cursor += m.height
+		case "home", "g":
+			m.cursor = 0
+		case "end", "G":
+			m.cursor = len(m.lines) - 1
+		case "enter":
+			if m.cursor < len(m.lines) {
+				hash := m.lines[m.cursor].Hash
+				return m, func() tea.Msg { return showCommitMsg{hash: hash} }
+			}
+		}
+		m.cursor = max(min(m.cursor, len(m.lines)-1), 0)
+		m.scroll()
+	}
+	return m, nil
+}
+
+// scroll keeps the cursor on screen
+func (m *blameModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	m.offset = max(m.offset, 0)
+}
+
+func (m blameModel) View() string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#606060"))
+	authorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true)
+
+	switch {
+	case m.loading:
+		return dim.Render("Blaming " + m.path + "...")
+	case m.err != "":
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Render(m.err)
+	case len(m.lines) == 0:
+		return dim.Render(m.path + " is empty")
+	}
+
+	width := len(fmt.Sprint(len(m.lines)))
+	var b strings.Builder
+	end := min(m.offset+m.height, len(m.lines))
+	for i := m.offset; i < end; i++ {
+		line := m.lines[i]
+
+		// Only the first of a run of lines from the same commit is annotated
+		annotation := strings.Repeat(" ", 7+1+14+1+11)
+		if i == m.offset || m.lines[i-1].Hash != line.Hash {
+			annotation = fmt.Sprintf("%s %s %s",
+				hashStyle.Render(line.Hash.String()[:7]),
+				authorStyle.Render(fmt.Sprintf("%-14s", truncate(line.AuthorName, 14))),
+				dim.Render(line.Date.Format("Jan 02 2006")))
+		}
+		if i == m.cursor {
+			annotation = selectedStyle.Render(fmt.Sprintf("%s %-14s %s",
+				line.Hash.String()[:7], truncate(line.AuthorName, 14), line.Date.Format("Jan 02 2006")))
+		}
+
+		code := ""
+		if i < len(m.code) {
+			code = strings.ReplaceAll(m.code[i], "\t", "    ")
+		}
+		row := annotation + " " + dim.Render(fmt.Sprintf("%*d │ ", width, i+1)) + code
+		b.WriteString(ansi.Truncate(row, m.width, "") + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
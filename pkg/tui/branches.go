@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// branchHistoryMsg asks the Commit History tab to show a branch
+type branchHistoryMsg struct {
+	ref refOption
+}
+
+// branchItem is a branch and its last commit
+type branchItem struct {
+	name    plumbing.ReferenceName
+	hash    string
+	subject string
+	author  string
+	date    time.Time
+	// Commits the branch has that the default branch lacks, and the other
+	// way round; -1 when they could not be counted
+	ahead, behind int
+	isDefault     bool
+}
+
+func (i branchItem) FilterValue() string { return i.name.Short() }
+
+// branchesModel lists the branches of the repository, most recently updated first
+type branchesModel struct {
+	repo  *git.Repository
+	path  string
+	list  list.Model
+	base  refOption // Default branch the others are compared with
+	err   string
+	width int
+}
+
+func newBranches(repo *git.Repository, repoPath string) branchesModel {
+	l := list.New(nil, branchDelegate{}, 0, 0)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.KeyMap.Quit.SetEnabled(false)
+
+	m := branchesModel{repo: repo, path: repoPath, list: l}
+	m.reload()
+	return m
+}
+
+// reload lists the branches again, keeping the selection on the same branch
+func (m *branchesModel) reload() {
+	m.base = defaultRef(m.repo)
+
+	format := "--format=%(refname)%1f%(objectname)%1f%(authorname)%1f%(committerdate:unix)%1f%(subject)"
+	out, err := exec.Command("git", "-C", m.path, "for-each-ref", "--sort=-committerdate", format, "refs/heads").Output()
+	if err != nil {
+		m.err = "Could not list branches: " + err.Error()
+		return
+	}
+	m.err = ""
+
+	var selected plumbing.ReferenceName
+	if i, ok := m.list.SelectedItem().(branchItem); ok {
+		selected = i.name
+	}
+
+	var items []list.Item
+	index := 0
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x1f", 5)
+		if len(fields) < 5 {
+			continue
+		}
+		sec, _ := strconv.ParseInt(fields[3], 10, 64)
+		item := branchItem{
+			name:      plumbing.ReferenceName(fields[0]),
+			hash:      fields[1],
+			author:    fields[2],
+			date:      time.Unix(sec, 0),
+			subject:   fields[4],
+			isDefault: plumbing.ReferenceName(fields[0]) == m.base.name,
+		}
+		item.ahead, item.behind = m.aheadBehind(item.name)
+		if item.name == selected {
+			index = len(items)
+		}
+		items = append(items, item)
+	}
+	m.list.SetItems(items)
+	m.list.Select(index)
+}
+
+// aheadBehind counts the commits unique to a branch and to the default branch
+func (m branchesModel) aheadBehind(branch plumbing.ReferenceName) (ahead, behind int) {
+	if branch == m.base.name {
+		return 0, 0
+	}
+	out, err := exec.Command("git", "-C", m.path, "rev-list", "--left-right", "--count",
+		m.base.name.String()+"..."+branch.String()).Output()
+	if err != nil {
+		return -1, -1
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return -1, -1
+	}
+	behind, _ = strconv.Atoi(fields[0])
+	ahead, _ = strconv.Atoi(fields[1])
+	return ahead, behind
+}
+
+func (m branchesModel) Update(msg tea.Msg) (branchesModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.list.SetSize(msg.Width, max(msg.Height-1, 1)) // Help
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+		if msg.String() == "enter" {
+			if i, ok := m.list.SelectedItem().(branchItem); ok {
+				ref := refOption{name: i.name}
+				return m, func() tea.Msg { return branchHistoryMsg{ref: ref} }
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m branchesModel) View() string {
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	help := helpStyle.Render(fmt.Sprintf("Ahead/behind %s  [up/down] Select  [enter] Show history  [/] Filter  [tab] Switch tab", m.base.Label()))
+
+	if m.err != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Render(m.err), help)
+	}
+	if len(m.list.Items()) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left, helpStyle.Render("No branches yet."), help)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.list.View(), help)
+}
+
+type branchDelegate struct{}
+
+func (d branchDelegate) Height() int                               { return 2 }
+func (d branchDelegate) Spacing() int                              { return 1 }
+func (d branchDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d branchDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	b, ok := listItem.(branchItem)
+	if !ok {
+		return
+	}
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	label := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+
+	name := branchStyle.Render(b.name.Short())
+	if index == m.Index() {
+		name = lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true).Render(b.name.Short())
+	}
+
+	var status string
+	switch {
+	case b.isDefault:
+		status = label.Render("default")
+	case b.ahead < 0:
+		status = dim.Render("no common history")
+	default:
+		status = addStyle.Render(fmt.Sprintf("↑%d", b.ahead)) + " " + delStyle.Render(fmt.Sprintf("↓%d", b.behind))
+	}
+
+	line1 := name + "  " + status
+	line2 := dim.Render(shortHash(b.hash)) + " " +
+		label.Render(truncate(b.subject, max(m.Width()-40, 10))) + " " +
+		dim.Render(fmt.Sprintf("%s, %s", b.author, b.date.Local().Format("Jan 02, 2006")))
+
+	fmt.Fprint(w, lipgloss.NewStyle().PaddingLeft(2).Render(line1+"\n"+line2))
+}
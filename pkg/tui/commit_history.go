@@ -3,10 +3,12 @@ package tui
 import (
 	"fmt"
 	"io"
+	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/go-git/go-git/v5"
@@ -25,6 +27,8 @@ var (
 
 type CommitItem struct {
 	hash, desc, user, time string
+	refs                   []string // Branch and tag labels pointing at the commit
+	graph                  []string // Rows of the commit graph beside it, nil outside the history
 }
 
 // Getters for item
@@ -32,40 +36,169 @@ func (i CommitItem) Hash() string        { return i.hash }
 func (i CommitItem) Description() string { return i.desc }
 func (i CommitItem) User() string        { return i.user }
 func (i CommitItem) Time() string        { return i.time }
+func (i CommitItem) Refs() []string      { return i.refs }
 func (i CommitItem) FilterValue() string { return i.desc }
 
 type commitModel struct {
 	list         list.Model
-	viewport     viewport.Model
+	diff         diffView
 	repo         *git.Repository
 	ready        bool
 	focus        bool   // false = List focused, true = Viewport focused
 	selectedHash string // Track current commit to avoid diff re-calculation
+
+	path    string
+	source  *commitSource // History being listed
+	loading bool          // A page of the history is being loaded
+	total   int           // Commits in the history, -1 until counted
+	picking bool          // The ref picker is open
+	graph   bool          // The commit graph is drawn beside the history
+	canTag  bool          // Commits can be tagged as releases, which only admins create
+
+	compareStep int       // 1 while picking the ref to compare, 2 while picking its base
+	compareHead refOption // Ref being compared, once picked
+	comparing   bool      // The comparison is shown
+	compare     compareModel
+
+	searchInput textinput.Model
+	editing     bool          // The search bar is open
+	search      *commitSearch // Search whose results are listed, nil for the history
+	searchErr   string
+	history     []list.Item // History loaded before the search, restored after it
+	picker      list.Model
+	width       int
+	height      int
 }
 
 func (m commitModel) Init() tea.Cmd {
-	return nil
+	return countCommits(m.path, m.source)
 }
 
 func (m commitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if m.picking {
+		return m.updatePicker(msg)
+	}
+	if m.editing {
+		return m.updateSearchBar(msg)
+	}
+	if m.comparing {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			return m.updateCompare(msg)
+		case tea.WindowSizeMsg:
+			// Resize the history as well, for when the comparison is closed
+			m.compare, _ = m.compare.Update(msg)
+		}
+	}
+
 	switch msg := msg.(type) {
+	case commitPageMsg:
+		msg.source.walker = msg.walker
+		if msg.source != m.source {
+			return m, nil // A page of a history no longer shown
+		}
+		m.loading = false
+		page := m.source.items(msg.commits)
+		if m.search != nil {
+			// Keep the page for when the search is closed
+			m.history = append(m.history, page...)
+			return m, nil
+		}
+		items := append(append([]list.Item{}, m.list.Items()...), page...)
+		cmds = append(cmds, forList(m.list.SetItems(items)), m.loadMore())
+		return m, tea.Batch(cmds...)
+
+	case commitSearchMsg:
+		if msg.search != m.search {
+			return m, nil // A search that was closed or replaced
+		}
+		m.search.done = true
+		m.search.truncated = msg.truncated
+		m.showItems(msg.items)
+		return m, nil
+
+	case commitListMsg:
+		var cmd tea.Cmd
+		m.list, cmd = m.list.Update(msg.msg)
+		return m, cmd
+
+	case commitCountMsg:
+		if msg.source == m.source {
+			m.total = msg.count
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
 		switch msg.String() {
+		case "b":
+			if !m.focus {
+				m.picking = true
+				m.picker = newRefPicker(listRefs(m.repo), m.width, m.height-1)
+				return m, nil
+			}
+		case "c":
+			if !m.focus {
+				m.picking = true
+				m.compareStep = 1
+				m.picker = newRefPicker(listRefs(m.repo)[1:], m.width, m.height-1)
+				m.picker.Title = "Compare"
+				return m, nil
+			}
+		case "g":
+			if !m.focus {
+				m.graph = !m.graph
+				m.list.SetDelegate(m.delegate())
+				return m, nil
+			}
+		case "a":
+			if i, ok := m.list.SelectedItem().(CommitItem); ok {
+				hash := plumbing.NewHash(i.hash)
+				files := changedFiles(m.repo, hash)
+				return m, func() tea.Msg { return blameRequestMsg{commit: hash, files: files} }
+			}
+		case "t":
+			if !m.canTag {
+				break
+			}
+			if i, ok := m.list.SelectedItem().(CommitItem); ok {
+				hash := i.hash
+				return m, func() tea.Msg { return tagCommitMsg{hash: hash} }
+			}
+		case "s":
+			if !m.focus {
+				m.editing = true
+				m.searchErr = ""
+				if m.search != nil {
+					m.searchInput.SetValue(m.search.query)
+				}
+				m.searchInput.CursorEnd()
+				return m, m.searchInput.Focus()
+			}
 		case "enter":
 			m.focus = !m.focus
-			m.list.SetDelegate(commitDelegate{listFocused: !m.focus})
+			m.list.SetDelegate(m.delegate())
 			return m, nil
 		case "esc":
 			if m.focus {
 				m.focus = false
-				m.list.SetDelegate(commitDelegate{listFocused: true})
+				m.list.SetDelegate(m.delegate())
 				return m, nil
 			}
+			if m.search != nil && m.list.FilterState() == list.Unfiltered {
+				m.closeSearch()
+				return m, m.loadMore()
+			}
 		}
 
 	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
 		helpHeight := 1
 		borderHeight := 2
 		targetHeight := msg.Height - helpHeight - borderHeight
@@ -90,11 +223,10 @@ func (m commitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		viewportHeight := targetHeight
 
 		if !m.ready {
-			m.viewport = viewport.New(viewWidth, viewportHeight)
+			m.diff = newDiffView(viewWidth, viewportHeight)
 			m.ready = true
 		} else {
-			m.viewport.Width = viewWidth
-			m.viewport.Height = viewportHeight
+			m.diff.setSize(viewWidth, viewportHeight)
 		}
 
 	}
@@ -108,21 +240,215 @@ func (m commitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if i, ok := m.list.SelectedItem().(CommitItem); ok {
 			if i.hash != m.selectedHash {
 				m.selectedHash = i.hash
-				rawDiff := getCommitDiff(m.repo, i.hash)
-				m.viewport.SetContent(highlightDiff(rawDiff))
-				m.viewport.GotoTop()
+				m.diff.setDiff(getCommitDiff(m.path, i.hash))
 			}
 		}
+		cmds = append(cmds, m.loadMore())
 	} else {
 		var viewCmd tea.Cmd
-		m.viewport, viewCmd = m.viewport.Update(msg)
+		m.diff, viewCmd = m.diff.Update(msg)
 		cmds = append(cmds, viewCmd)
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// updatePicker handles the ref picker, loading the chosen ref's history
+func (m commitModel) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.picker.FilterState() != list.Filtering {
+		switch key.String() {
+		case "esc":
+			if m.picker.FilterState() == list.Unfiltered {
+				m.picking = false
+				m.compareStep = 0
+				return m, nil
+			}
+		case "enter":
+			opt, ok := m.picker.SelectedItem().(refOption)
+			if !ok {
+				return m, nil
+			}
+			switch m.compareStep {
+			case 1:
+				m.compareHead = opt
+				m.compareStep = 2
+				m.picker = newRefPicker(listRefs(m.repo)[1:], m.width, m.height-1)
+				m.picker.Title = "Compare " + opt.Label() + " against"
+				return m, nil
+			case 2:
+				m.picking = false
+				m.compareStep = 0
+				m.comparing = true
+				m.compare = newCompare(m.path, m.compareHead, opt, m.width, m.height)
+				return m, nil
+			}
+			m.picking = false
+			return m, m.showRef(opt)
+		}
+	}
+
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width, m.height = size.Width, size.Height
+		m.picker.SetSize(size.Width, size.Height-1)
+	}
+
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	return m, cmd
+}
+
+// updateCompare handles the comparison, going back to the history on esc
+func (m commitModel) updateCompare(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		if m.compare.focus {
+			m.compare.focus = false
+		} else {
+			m.comparing = false
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.compare, cmd = m.compare.Update(msg)
+	return m, cmd
+}
+
+// updateSearchBar edits the search, running it over the whole history on enter
+func (m commitModel) updateSearchBar(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc":
+			m.editing = false
+			m.searchInput.Blur()
+			return m, nil
+		case "enter":
+			input := strings.TrimSpace(m.searchInput.Value())
+			if input == "" {
+				m.editing = false
+				m.searchInput.Blur()
+				if m.search != nil {
+					m.closeSearch()
+					return m, m.loadMore()
+				}
+				return m, nil
+			}
+
+			q, err := parseCommitQuery(input)
+			if err != nil {
+				m.searchErr = err.Error()
+				return m, nil
+			}
+			m.editing = false
+			m.searchInput.Blur()
+			if m.search == nil {
+				m.history = m.list.Items()
+			}
+			m.search = &commitSearch{query: input}
+			m.showItems([]list.Item{})
+			return m, runCommitSearch(m.path, m.source, m.search, q)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	return m, cmd
+}
+
+// showPathHistory lists the commits of a ref that touched a file, or a
+// file under a directory, matching its path exactly as lastCommit does
+func (m *commitModel) showPathHistory(opt refOption, filePath string) tea.Cmd {
+	count := m.showRef(opt)
+	if filePath == "" {
+		return count
+	}
+
+	input := fmt.Sprintf("path:%q", filePath)
+	q := commitQuery{path: filePath, exactPath: true}
+	m.history = m.list.Items()
+	m.search = &commitSearch{query: input}
+	m.showItems([]list.Item{})
+	return tea.Batch(count, runCommitSearch(m.path, m.source, m.search, q))
+}
+
+// showCommit lists a single commit with its diff, wherever it is in the history
+func (m *commitModel) showCommit(hash plumbing.Hash) {
+	c, err := m.repo.CommitObject(hash)
+	if err != nil {
+		return
+	}
+	if m.search == nil {
+		m.history = m.list.Items()
+	}
+	m.search = &commitSearch{query: hash.String()[:7], done: true}
+	m.focus = false
+	m.list.SetDelegate(m.delegate())
+	m.showItems([]list.Item{m.source.item(c)})
+}
+
+// closeSearch lists the history again
+func (m *commitModel) closeSearch() {
+	m.search = nil
+	m.showItems(m.history)
+	m.history = nil
+}
+
+// showItems replaces the listed commits and shows the first one's diff
+func (m *commitModel) showItems(items []list.Item) {
+	m.list.ResetFilter()
+	m.list.SetItems(items)
+	m.list.Select(0)
+
+	m.selectedHash = ""
+	if i, ok := m.list.SelectedItem().(CommitItem); ok {
+		m.selectedHash = i.hash
+		m.diff.setDiff(getCommitDiff(m.path, i.hash))
+	} else if m.search != nil && !m.search.done {
+		m.diff.setMessage("Searching...")
+	} else if m.search != nil {
+		m.diff.setMessage("No commits match the search")
+	} else {
+		m.diff.setMessage("No commits on " + m.source.ref.Label())
+	}
+}
+
+// loadMore fetches the next page once the selection nears the end of the
+// list. Filtering needs the whole history, so it keeps loading until done.
+func (m *commitModel) loadMore() tea.Cmd {
+	if m.loading || m.source.done() || m.search != nil {
+		return nil
+	}
+	if m.list.FilterState() == list.Unfiltered && m.list.Index() < len(m.list.Items())-10 {
+		return nil
+	}
+	m.loading = true
+	return loadCommitPage(m.source)
+}
+
+// showRef lists the history of a ref and shows its newest commit
+func (m *commitModel) showRef(opt refOption) tea.Cmd {
+	m.source = newCommitSource(m.repo, m.path, opt)
+	m.loading = false
+	m.total = -1
+	m.search, m.history = nil, nil
+	m.showItems(m.source.page(commitPageSize))
+	return countCommits(m.path, m.source)
+}
+
 func (m commitModel) View() string {
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	if m.picking {
+		action := "Show history"
+		if m.compareStep > 0 {
+			action = "Choose"
+		}
+		help := helpStyle.Render("[up/down] Select  [/] Filter  [enter] " + action + "  [esc] Cancel")
+		return lipgloss.JoinVertical(lipgloss.Left, m.picker.View(), help)
+	}
+	if m.comparing {
+		return m.compare.View()
+	}
+
 	borderColor := lipgloss.Color("238")
 	if m.focus {
 		borderColor = lipgloss.Color("#5000ff")
@@ -136,21 +462,86 @@ func (m commitModel) View() string {
 	content := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		m.list.View(),
-		vpStyle.Render(m.viewport.View()),
+		vpStyle.Render(m.diff.View()),
 	)
 
-	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
-	help := helpStyle.Render("[up/down] Navigate commits  [enter] Toggle diff focus  [esc] Leave diff  [tab] Switch tab")
+	if m.editing {
+		bar := m.searchInput.View()
+		if m.searchErr != "" {
+			bar = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Render(m.searchErr) + "  " + bar
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, content, bar)
+	}
+
+	ref := m.source.ref
+	refLabel := branchStyle.Render(ref.Label())
+	if ref.all {
+		refLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true).Render(ref.Label())
+	} else if ref.name.IsTag() {
+		refLabel = tagStyle.Render(ref.Label())
+	}
+	tag := ""
+	if m.canTag {
+		tag = "[t] Tag  "
+	}
+	keys := "[b] Switch ref  [c] Compare  [s] Search  [g] Graph  [a] Blame  " + tag + "[up/down] Navigate commits  [enter] Toggle diff focus  [tab] Switch tab"
+	if m.focus {
+		keys = diffHelp + "  [enter/esc] Leave diff  [tab] Switch tab"
+	}
+	help := refLabel + " " + helpStyle.Render(m.countLabel()) + "  " + helpStyle.Render(keys)
 
 	return lipgloss.JoinVertical(lipgloss.Left, content, help)
 }
 
+// countLabel describes how much of the history is loaded
+func (m commitModel) countLabel() string {
+	loaded := len(m.list.Items())
+	if m.search != nil {
+		switch {
+		case !m.search.done:
+			return fmt.Sprintf("searching for %q...", m.search.query)
+		case m.search.truncated:
+			return fmt.Sprintf("first %d matches for %q, [esc] back to history", loaded, m.search.query)
+		default:
+			return fmt.Sprintf("%s for %q, [esc] back to history", plural(loaded, "match"), m.search.query)
+		}
+	}
+	switch {
+	case m.total < 0 && m.source.done():
+		return plural(loaded, "commit")
+	case m.total < 0:
+		return fmt.Sprintf("%d+ commits", loaded)
+	case loaded < m.total:
+		return fmt.Sprintf("%s, %d loaded", plural(m.total, "commit"), loaded)
+	default:
+		return plural(m.total, "commit")
+	}
+}
+
+// delegate draws the commits for the current focus and graph setting
+func (m commitModel) delegate() commitDelegate {
+	return commitDelegate{listFocused: !m.focus, graph: m.graph}
+}
+
 type commitDelegate struct {
 	listFocused bool
+	graph       bool // Draw the commit graph, in place of the space between commits
+}
+
+func (d commitDelegate) Height() int {
+	if d.graph {
+		return 3
+	}
+	return 2
+}
+
+func (d commitDelegate) Spacing() int {
+	if d.graph {
+		return 0
+	}
+	return 1
 }
 
-func (d commitDelegate) Height() int                               { return 2 }
-func (d commitDelegate) Spacing() int                              { return 1 }
 func (d commitDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
 
 func (d commitDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
@@ -164,7 +555,12 @@ func (d commitDelegate) Render(w io.Writer, m list.Model, index int, listItem li
 		listWidth = 30
 	}
 
-	availWidth := listWidth - 11 // Adjusted because we removed the border width
+	graph := []string{"", "", ""}
+	if d.graph && len(i.graph) == 3 {
+		graph = i.graph
+	}
+
+	availWidth := listWidth - 11 - lipgloss.Width(graph[0]) // Adjusted because we removed the border width
 	if availWidth < 10 {
 		availWidth = 10
 	}
@@ -188,7 +584,17 @@ func (d commitDelegate) Render(w io.Writer, m list.Model, index int, listItem li
 		Foreground(hashColor).
 		Bold(isSelected) // Bold the hash to make it pop even more
 
-	descStyle := lipgloss.NewStyle().Width(availWidth)
+	// Branch and tag labels go between the hash and the message
+	decoration := ""
+	if len(i.refs) > 0 {
+		decoration = renderDecorations(i.refs)
+	}
+	descWidth := availWidth - lipgloss.Width(decoration)
+	if descWidth < 10 {
+		descWidth = 10
+	}
+
+	descStyle := lipgloss.NewStyle().Width(descWidth)
 
 	// 3. Clean up the base style (Removed the border logic)
 	fn := lipgloss.NewStyle().PaddingLeft(2)
@@ -202,89 +608,135 @@ func (d commitDelegate) Render(w io.Writer, m list.Model, index int, listItem li
 	timeInfo := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050")).Render("authored " + i.time)
 
 	// Render the line with the newly colored hash
-	line1 := lipgloss.JoinHorizontal(lipgloss.Top, hashStyle.Render(shortHash)+"  ", descStyle.Render(i.desc))
-	line2 := fmt.Sprintf("%s %s", userInfo, timeInfo)
+	line1 := lipgloss.JoinHorizontal(lipgloss.Top, graph[0]+hashStyle.Render(shortHash)+"  "+decoration, descStyle.Render(i.desc))
+	line2 := fmt.Sprintf("%s%s %s", graph[1], userInfo, timeInfo)
+	if d.graph {
+		line2 += "\n" + graph[2]
+	}
 
 	fmt.Fprint(w, fn.Render(line1+"\n"+line2))
 }
-func fetchCommits(repoPath string, limit int) ([]list.Item, error) {
-	repo, err := git.PlainOpen(repoPath)
-	if err != nil {
-		return nil, err
-	}
 
-	ref, err := repo.Head()
-	if err != nil {
-		// If there's no HEAD (empty repo), return empty list instead of error
-		return []list.Item{}, nil
-	}
+// commitPageSize is how many commits are loaded at a time
+const commitPageSize = 50
 
-	cIter, err := repo.Log(&git.LogOptions{From: ref.Hash()})
-	if err != nil {
-		return nil, err
-	}
+// commitPageMsg carries the next page of a history being browsed, and hands
+// back the walker that loaded it
+type commitPageMsg struct {
+	source  *commitSource
+	walker  *commitWalker
+	commits []*object.Commit
+}
 
-	var items []list.Item
-	count := 0
-	err = cIter.ForEach(func(c *object.Commit) error {
-		if count >= limit {
-			return io.EOF // Stop iterating
-		}
+// commitCountMsg carries the total number of commits in a history
+type commitCountMsg struct {
+	source *commitSource
+	count  int
+}
 
-		// Clean up trailing whitespace but keep the whole message
-		msg := strings.TrimSpace(c.Message)
-
-		items = append(items, CommitItem{
-			hash: c.Hash.String(),
-			desc: msg,
-			user: c.Author.Name,
-			time: c.Author.When.Format("Jan 02, 2006"),
-		})
-		count++
+// commitListMsg wraps the commit list's own messages, such as refreshed
+// filter matches, so they reach it while another tab is open
+type commitListMsg struct{ msg tea.Msg }
+
+// forList routes the result of a list command back to the commit list
+func forList(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
 		return nil
-	})
+	}
+	return func() tea.Msg {
+		return commitListMsg{msg: cmd()}
+	}
+}
 
-	if err != nil && err != io.EOF {
-		return nil, err
+// commitSource pages through the history of a ref, or of every ref merged
+// by date, so only the commits scrolled to are ever loaded
+type commitSource struct {
+	ref         refOption
+	decorations map[plumbing.Hash][]string
+	graph       commitGraph // Carried from page to page
+
+	// walker reads the history with a repository of its own, as pages are
+	// loaded in the background while the TUI uses its own. A page being
+	// loaded takes the walker over, leaving it nil until its commitPageMsg
+	// hands it back.
+	walker *commitWalker
+}
+
+func newCommitSource(repo *git.Repository, repoPath string, opt refOption) *commitSource {
+	source := &commitSource{
+		ref:         opt,
+		decorations: refDecorations(repo),
+		walker:      newCommitWalker(nil),
+	}
+	// An empty repo, or a ref that no longer exists, has no tips and no history
+	if walked, err := git.PlainOpen(repoPath); err == nil {
+		source.walker = newCommitWalker(refTips(walked, opt))
 	}
-	return items, nil
+	return source
 }
 
-func getCommitDiff(repo *git.Repository, hash string) string {
-	h := plumbing.NewHash(hash)
-	commit, err := repo.CommitObject(h)
-	if err != nil {
-		return "Error finding commit"
+// done reports whether the whole history has been loaded
+func (s *commitSource) done() bool {
+	return s.walker != nil && s.walker.done()
+}
+
+// page loads up to n more commits right away, for a history just opened
+func (s *commitSource) page(n int) []list.Item {
+	return s.items(s.walker.next(n))
+}
+
+// items lists commits of the history, labelled with the branches and tags
+// pointing at them, and draws the graph beside them
+func (s *commitSource) items(commits []*object.Commit) []list.Item {
+	items := []list.Item{}
+	for _, c := range commits {
+		item := s.item(c)
+		item.graph = s.graph.rows(c)
+		items = append(items, item)
 	}
+	return items
+}
 
-	parent, err := commit.Parent(0)
-	var patch *object.Patch
-	if err != nil {
-		// This is the first commit, diff against empty tree
-		patch, _ = commit.Patch(nil)
-	} else {
-		patch, _ = parent.Patch(commit)
+// item lists a commit of the history
+func (s *commitSource) item(c *object.Commit) CommitItem {
+	return CommitItem{
+		hash: c.Hash.String(),
+		// Clean up trailing whitespace but keep the whole message
+		desc: strings.TrimSpace(c.Message),
+		user: c.Author.Name,
+		time: c.Author.When.Format("Jan 02, 2006"),
+		refs: s.decorations[c.Hash],
 	}
+}
 
-	if patch == nil {
-		return "No changes found."
+// loadCommitPage loads the next page of a history in the background. It
+// takes the source's walker over until the page arrives.
+func loadCommitPage(source *commitSource) tea.Cmd {
+	walker := source.walker
+	source.walker = nil
+	return func() tea.Msg {
+		return commitPageMsg{source: source, walker: walker, commits: walker.next(commitPageSize)}
 	}
-	return patch.String()
 }
 
-func highlightDiff(rawDiff string) string {
-	lines := strings.Split(rawDiff, "\n")
-	for i, line := range lines {
-		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-			lines[i] = addStyle.Render(line)
-		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
-			lines[i] = delStyle.Render(line)
-		} else if strings.HasPrefix(line, "@@") {
-			lines[i] = headerStyle.Render(line)
+// countCommits counts a history with git, which is much faster than walking it
+func countCommits(repoPath string, source *commitSource) tea.Cmd {
+	return func() tea.Msg {
+		args := []string{"-C", repoPath, "rev-list", "--count"}
+		if source.ref.all {
+			args = append(args, "--branches", "--tags")
 		} else {
-			// Apply base style to everything else (filenames, context, etc.)
-			lines[i] = baseDiffStyle.Render(line)
+			args = append(args, source.ref.name.String())
+		}
+
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return commitCountMsg{source: source, count: -1}
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(string(out)))
+		if err != nil {
+			count = -1
 		}
+		return commitCountMsg{source: source, count: count}
 	}
-	return strings.Join(lines, "\n")
 }
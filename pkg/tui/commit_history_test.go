@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// gitIn runs a git command for a test at a fixed commit time, failing it on error
+func gitIn(t *testing.T, dir string, when time.Time, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	stamp := when.Format(time.RFC3339)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+stamp, "GIT_COMMITTER_DATE="+stamp)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setupHistory creates a repository where main and feature each get commits
+// alternately after a shared base, and returns it with the commit subjects
+// newest first
+func setupHistory(t *testing.T, perBranch int) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	gitIn(t, dir, base, "init", "--quiet", "--initial-branch=main")
+	gitIn(t, dir, base, "commit", "--quiet", "--allow-empty", "-m", "base")
+	gitIn(t, dir, base, "branch", "feature")
+
+	subjects := []string{"base"}
+	for i := 1; i <= perBranch; i++ {
+		for j, branch := range []string{"main", "feature"} {
+			when := base.Add(time.Duration(2*i+j) * time.Minute)
+			subject := fmt.Sprintf("%s %d", branch, i)
+			gitIn(t, dir, when, "checkout", "--quiet", branch)
+			gitIn(t, dir, when, "commit", "--quiet", "--allow-empty", "-m", subject)
+			subjects = append([]string{subject}, subjects...)
+		}
+	}
+	return filepath.Join(dir, ".git"), subjects
+}
+
+func TestCommitSourcePages(t *testing.T) {
+	repoPath, subjects := setupHistory(t, 60)
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := newCommitSource(repo, repoPath, refOption{all: true})
+	items := source.page(commitPageSize)
+	for !source.done() {
+		// The TUI keeps using its repository while a page loads
+		done := make(chan commitPageMsg)
+		cmd := loadCommitPage(source)
+		go func() { done <- cmd().(commitPageMsg) }()
+		listRefs(repo)
+		source.done()
+		msg := <-done
+
+		msg.source.walker = msg.walker
+		items = append(items, source.items(msg.commits)...)
+	}
+
+	var got []string
+	for _, item := range items {
+		i := item.(CommitItem)
+		if len(i.graph) != 3 {
+			t.Fatalf("commit %q has no graph", i.desc)
+		}
+		got = append(got, i.desc)
+	}
+	if strings.Join(got, "|") != strings.Join(subjects, "|") {
+		t.Errorf("history = %v, want %v", got, subjects)
+	}
+}
+
+func TestCommitWalkerSameTime(t *testing.T) {
+	// Commits made in the same second come out in the order they're reached
+	dir := t.TempDir()
+	when := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	gitIn(t, dir, when, "init", "--quiet", "--initial-branch=main")
+	var want []string
+	for i := 0; i < 5; i++ {
+		subject := fmt.Sprintf("commit %d", i)
+		gitIn(t, dir, when, "commit", "--quiet", "--allow-empty", "-m", subject)
+		want = append([]string{subject}, want...)
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	walker := newCommitWalker(refTips(repo, refOption{name: plumbing.NewBranchReferenceName("main")}))
+	var got []string
+	for _, c := range walker.next(10) {
+		got = append(got, strings.TrimSpace(c.Message))
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") || !walker.done() {
+		t.Errorf("walked %v, want %v", got, want)
+	}
+}
+
+func TestRunCommitSearch(t *testing.T) {
+	repoPath, _ := setupHistory(t, 10)
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := newCommitSource(repo, repoPath, refOption{name: plumbing.NewBranchReferenceName("feature")})
+
+	q, err := parseCommitQuery("Feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := runCommitSearch(repoPath, source, &commitSearch{}, q)
+	result := make(chan commitSearchMsg)
+	go func() { result <- cmd().(commitSearchMsg) }()
+	source.page(commitPageSize)
+	msg := <-result
+
+	if len(msg.items) != 10 {
+		t.Fatalf("found %d commits, want 10", len(msg.items))
+	}
+	for _, item := range msg.items {
+		if desc := item.(CommitItem).desc; !strings.HasPrefix(desc, "feature ") {
+			t.Errorf("found %q on feature", desc)
+		}
+	}
+}
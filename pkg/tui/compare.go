@@ -0,0 +1,257 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxCompareCommits bounds how many unique commits each side of a comparison lists
+const maxCompareCommits = 200
+
+// compareEntry is a line of the comparison list: the cumulative diff, or a
+// commit unique to one side
+type compareEntry struct {
+	commit *CommitItem // nil for the cumulative diff
+	ahead  bool        // The commit is on head but not on base
+}
+
+// compareModel shows how one ref differs from another
+type compareModel struct {
+	path string
+	head refOption
+	base refOption
+
+	aheadCount, behindCount int
+	mergeBase               string
+	entries                 []compareEntry
+	cursor, offset          int
+	diff                    diffView
+	focus                   bool // false = List focused, true = Viewport focused
+	err                     string
+
+	width, height int
+}
+
+// newCompare compares head against base: the commits only on each side and
+// the changes head makes on top of their merge base
+func newCompare(repoPath string, head, base refOption, width, height int) compareModel {
+	m := compareModel{path: repoPath, head: head, base: base, diff: newDiffView(0, 0)}
+	m.resize(width, height)
+
+	gitOut := func(args ...string) (string, error) {
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", append([]string{"-C", repoPath}, args...)...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil && stderr.Len() > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+		}
+		return string(out), err
+	}
+
+	headRev, baseRev := head.name.String(), base.name.String()
+	mergeBase, err := gitOut("merge-base", baseRev, headRev)
+	if err != nil {
+		m.err = fmt.Sprintf("%s and %s share no history", head.Label(), base.Label())
+		return m
+	}
+	m.mergeBase = strings.TrimSpace(mergeBase)
+
+	counts, err := gitOut("rev-list", "--left-right", "--count", baseRev+"..."+headRev)
+	if err != nil {
+		m.err = "Could not count commits: " + err.Error()
+		return m
+	}
+	if fields := strings.Fields(counts); len(fields) == 2 {
+		m.behindCount, _ = strconv.Atoi(fields[0])
+		m.aheadCount, _ = strconv.Atoi(fields[1])
+	}
+
+	m.entries = []compareEntry{{}}
+	for _, side := range []struct {
+		revs  string
+		ahead bool
+	}{{baseRev + ".." + headRev, true}, {headRev + ".." + baseRev, false}} {
+		out, err := gitOut("log", fmt.Sprintf("-n%d", maxCompareCommits), "--format=%H%x1f%an%x1f%at%x1f%B%x1e", side.revs)
+		if err != nil {
+			m.err = "Could not list commits: " + err.Error()
+			return m
+		}
+		for _, record := range strings.Split(out, "\x1e") {
+			fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 4)
+			if len(fields) < 4 {
+				continue
+			}
+			sec, _ := strconv.ParseInt(fields[2], 10, 64)
+			m.entries = append(m.entries, compareEntry{commit: &CommitItem{
+				hash: fields[0],
+				desc: strings.TrimSpace(fields[3]),
+				user: fields[1],
+				time: time.Unix(sec, 0).Format("Jan 02, 2006"),
+			}, ahead: side.ahead})
+		}
+	}
+
+	m.showEntry()
+	return m
+}
+
+// showEntry fills the viewport with the diff of the entry under the cursor
+func (m *compareModel) showEntry() {
+	if m.cursor >= len(m.entries) {
+		return
+	}
+	entry := m.entries[m.cursor]
+	if entry.commit != nil {
+		m.diff.setDiff(getCommitDiff(m.path, entry.commit.hash))
+		return
+	}
+
+	// Three dots diff from the merge base, as a pull request would show
+	cmd := exec.Command("git", "-C", m.path, "diff", "--no-color", m.base.name.String()+"..."+m.head.name.String())
+	out, err := cmd.Output()
+	switch {
+	case err != nil:
+		m.diff.setMessage("Could not diff: " + err.Error())
+	case len(out) == 0:
+		m.diff.setMessage(m.head.Label() + " makes no changes on top of " + m.base.Label())
+	default:
+		m.diff.setDiff(string(out))
+	}
+}
+
+// resize lays out the commit list and the diff viewport
+func (m *compareModel) resize(width, height int) {
+	m.width, m.height = width, height
+	m.diff.setSize(max(width-width/2-4, 10), max(height-4, 1)) // Summary, help and viewport border
+	m.scroll()
+}
+
+// listHeight is how many entries fit beside the diff
+func (m compareModel) listHeight() int {
+	return max(m.height-2, 1) // Summary and help
+}
+
+// scroll keeps the cursor on screen
+func (m *compareModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.listHeight() {
+		m.offset = m.cursor - m.listHeight() + 1
+	}
+	m.offset = max(m.offset, 0)
+}
+
+func (m compareModel) Update(msg tea.Msg) (compareModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			m.focus = !m.focus
+			return m, nil
+		case "up", "k", "down", "j":
+			if m.focus {
+				break
+			}
+			if msg.String() == "up" || msg.String() == "k" {
+				m.cursor = max(m.cursor-1, 0)
+			} else {
+				m.cursor = min(m.cursor+1, len(m.entries)-1)
+			}
+			m.scroll()
+			m.showEntry()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.diff, cmd = m.diff.Update(msg)
+	return m, cmd
+}
+
+func (m compareModel) View() string {
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+	selected := lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true)
+
+	label := func(r refOption) string {
+		if r.name.IsTag() {
+			return tagStyle.Render(r.Label())
+		}
+		return branchStyle.Render(r.Label())
+	}
+	keys := "[up/down] Select  [enter] Toggle diff focus  [esc] Close comparison  [tab] Switch tab"
+	if m.focus {
+		keys = diffHelp + "  [enter/esc] Leave diff  [tab] Switch tab"
+	}
+	help := helpStyle.Render(keys)
+
+	if m.err != "" {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			"Comparing "+label(m.head)+" with "+label(m.base), "",
+			lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Render(m.err), help)
+	}
+
+	summary := fmt.Sprintf("%s is %s and %s %s  %s",
+		label(m.head),
+		addStyle.Render(fmt.Sprintf("%d ahead", m.aheadCount)),
+		delStyle.Render(fmt.Sprintf("%d behind", m.behindCount)),
+		label(m.base),
+		dim.Render("merge base "+shortHash(m.mergeBase)))
+
+	listWidth := m.width / 2
+	var rows []string
+	end := min(m.offset+m.listHeight(), len(m.entries))
+	for i := m.offset; i < end; i++ {
+		entry := m.entries[i]
+		var row string
+		switch {
+		case entry.commit == nil:
+			row = fmt.Sprintf("Changes on %s since the merge base", m.head.Label())
+		case entry.ahead:
+			row = addStyle.Render("+ ") + dim.Render(shortHash(entry.commit.hash)) + " " + firstLine(entry.commit.desc)
+		default:
+			row = delStyle.Render("- ") + dim.Render(shortHash(entry.commit.hash)) + " " + firstLine(entry.commit.desc)
+		}
+		if i == m.cursor {
+			row = selected.Render("> ") + row
+		} else {
+			row = "  " + row
+		}
+		rows = append(rows, ansi.Truncate(row, listWidth, "…"))
+	}
+
+	borderColor := lipgloss.Color("238")
+	if m.focus {
+		borderColor = lipgloss.Color("#5000ff")
+	}
+	vpStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1)
+
+	content := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(listWidth).Render(strings.Join(rows, "\n")),
+		vpStyle.Render(m.diff.View()))
+
+	return lipgloss.JoinVertical(lipgloss.Left, summary, content, help)
+}
+
+// firstLine is the subject line of a commit message
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
@@ -388,11 +388,8 @@ func (m dashboardModel) renderDeleteConfirm() string {
 // Helper functions to modify config/users
 
 func togglePublic() {
-	currentPublic := logger.GetConfigPublic()
-	newConfig := logger.ConfigData{
-		Public:      !currentPublic,
-		DefaultPerm: logger.GetConfigDefaultPerm(),
-	}
+	newConfig := logger.GetConfig()
+	newConfig.Public = !newConfig.Public
 	logger.SetConfig(newConfig)
 	if err := logger.WriteJSONFile(logger.Conf, newConfig); err != nil {
 		logger.Logger.Error("Failed to write config.json", "error", err)
@@ -402,6 +399,7 @@ func togglePublic() {
 }
 
 func cycleDefaultPerm() {
+	// auditor is left out: guests must never get access to the server logs
 	perms := []string{"none", "read", "write", "admin"}
 	current := logger.GetConfigDefaultPerm()
 
@@ -413,10 +411,8 @@ func cycleDefaultPerm() {
 		}
 	}
 
-	newConfig := logger.ConfigData{
-		Public:      logger.GetConfigPublic(),
-		DefaultPerm: perms[idx],
-	}
+	newConfig := logger.GetConfig()
+	newConfig.DefaultPerm = perms[idx]
 	logger.SetConfig(newConfig)
 	if err := logger.WriteJSONFile(logger.Conf, newConfig); err != nil {
 		logger.Logger.Error("Failed to write config.json", "error", err)
@@ -426,7 +422,7 @@ func cycleDefaultPerm() {
 }
 
 func cyclePermValue(current string, direction int) string {
-	perms := []string{"none", "read", "write", "admin"}
+	perms := []string{"none", "read", "auditor", "write", "admin"}
 	idx := 0
 
 	for i, p := range perms {
@@ -442,7 +438,7 @@ func cyclePermValue(current string, direction int) string {
 }
 
 func cycleUserPerm(key string) {
-	perms := []string{"none", "read", "write", "admin"}
+	perms := []string{"none", "read", "auditor", "write", "admin"}
 
 	users := auth.GetAllUsers()
 	user, exists := users[key]
@@ -462,7 +458,7 @@ func cycleUserPerm(key string) {
 }
 
 func createUser(key, name, perm string) {
-	if perm != "none" && perm != "read" && perm != "write" && perm != "admin" {
+	if perm != "none" && perm != "read" && perm != "auditor" && perm != "write" && perm != "admin" {
 		perm = "none"
 	}
 
@@ -0,0 +1,676 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// minSideBySideWidth is the narrowest diff pane that is split in two columns
+const minSideBySideWidth = 80
+
+// diffHelp lists the keys of a focused diff
+const diffHelp = "[n/p] Next/previous file  ]/[ Next/previous hunk  [v] Side by side"
+
+// Backgrounds of added and removed lines, and of the words they change,
+// which leave the foreground to syntax highlighting
+var (
+	addBackground     = lipgloss.Color("#16260F")
+	delBackground     = lipgloss.Color("#2B0F0F")
+	addWordBackground = lipgloss.Color("#23401B")
+	delWordBackground = lipgloss.Color("#4A1414")
+)
+
+var (
+	addWordStyle    = addStyle.Background(addWordBackground) // Changed words of an added line
+	delWordStyle    = delStyle.Background(delWordBackground) // Changed words of a removed line
+	fileHeaderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+)
+
+// diffFile is the part of a diff changing one file
+type diffFile struct {
+	name    string
+	status  string // "new file", "deleted", "renamed from ..." or empty
+	binary  bool
+	parents int // 1, or the number of parents of a merge's combined diff
+	added   int
+	deleted int
+	hunks   []diffHunk
+}
+
+// diffHunk is a run of changed lines and their context
+type diffHunk struct {
+	header   string
+	oldStart int
+	newStart int
+	lines    []diffLine
+}
+
+// diffLine is a line of a hunk. prefix holds one column per parent: ' ',
+// '+' or '-', or '\' for the "No newline at end of file" marker.
+type diffLine struct {
+	prefix string
+	text   string
+}
+
+// kind is '+' for a line added by the commit, '-' for one it removed, and
+// ' ' for context
+func (l diffLine) kind() byte {
+	switch {
+	case strings.Contains(l.prefix, "+"):
+		return '+'
+	case strings.Contains(l.prefix, "-"):
+		return '-'
+	case strings.HasPrefix(l.prefix, "\\"):
+		return '\\'
+	}
+	return ' '
+}
+
+// getCommitDiff returns the patch of a commit. Merge commits get git's
+// combined diff, showing only what the merge itself changed.
+func getCommitDiff(repoPath, hash string) string {
+	out, err := exec.Command("git", "-C", repoPath, "show", "--no-color", "--format=%P", "--cc", hash).Output()
+	if err != nil {
+		return "Error finding commit"
+	}
+	parents, patch, _ := strings.Cut(string(out), "\n")
+	patch = strings.TrimLeft(patch, "\n")
+
+	if len(strings.Fields(parents)) > 1 && patch == "" {
+		// A clean merge changes nothing of its own; show what it brought in
+		out, err := exec.Command("git", "-C", repoPath, "diff", "--no-color", hash+"^1", hash).Output()
+		if err != nil {
+			return "Error diffing merge"
+		}
+		return "Clean merge. Changes brought into its first parent:\n" + string(out)
+	}
+	if patch == "" {
+		return "No changes found."
+	}
+	return patch
+}
+
+// parseDiff splits git's diff output into files. Text before the first file,
+// such as a message, is returned as the preamble.
+func parseDiff(raw string) (preamble []string, files []diffFile) {
+	var file *diffFile
+	var hunk *diffHunk
+	inHunk := false
+
+	for _, line := range strings.Split(strings.TrimSuffix(raw, "\n"), "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+
+		if strings.HasPrefix(line, "diff --git ") || strings.HasPrefix(line, "diff --cc ") || strings.HasPrefix(line, "diff --combined ") {
+			files = append(files, diffFile{name: diffGitName(line), parents: 1})
+			file = &files[len(files)-1]
+			hunk, inHunk = nil, false
+			continue
+		}
+		if file == nil {
+			preamble = append(preamble, line)
+			continue
+		}
+
+		if strings.HasPrefix(line, "@@") {
+			ats := len(line) - len(strings.TrimLeft(line, "@"))
+			file.parents = max(ats-1, 1)
+			file.hunks = append(file.hunks, diffHunk{header: line})
+			hunk = &file.hunks[len(file.hunks)-1]
+			hunk.oldStart, hunk.newStart = hunkStarts(line)
+			inHunk = true
+			continue
+		}
+
+		if inHunk {
+			n := min(file.parents, len(line))
+			if strings.HasPrefix(line, "\\") {
+				n = 1
+			}
+			l := diffLine{prefix: line[:n], text: line[n:]}
+			switch l.kind() {
+			case '+':
+				file.added++
+			case '-':
+				file.deleted++
+			}
+			hunk.lines = append(hunk.lines, l)
+			continue
+		}
+
+		// Extended header lines, between "diff --git" and the first hunk
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			if name := strings.TrimPrefix(line, "+++ "); name != "/dev/null" {
+				file.name = strings.TrimPrefix(name, "b/")
+			}
+		case strings.HasPrefix(line, "new file mode"):
+			file.status = "new file"
+		case strings.HasPrefix(line, "deleted file mode"):
+			file.status = "deleted"
+		case strings.HasPrefix(line, "rename from "):
+			file.status = "renamed from " + strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			file.name = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+			file.binary = true
+		}
+	}
+	return preamble, files
+}
+
+// diffGitName guesses the path of a file from its "diff --git a/x b/x"
+// line. Binary files and renames have no ---/+++ lines to correct it.
+func diffGitName(line string) string {
+	for _, prefix := range []string{"diff --cc ", "diff --combined "} {
+		if name, ok := strings.CutPrefix(line, prefix); ok {
+			return name
+		}
+	}
+	paths := strings.TrimPrefix(line, "diff --git ")
+	// Both paths are the same unless the file was renamed
+	return strings.TrimPrefix(paths[(len(paths)+1)/2:], "b/")
+}
+
+// hunkStarts reads the first old and new line numbers of a hunk header,
+// "@@ -1,2 +3,4 @@"
+func hunkStarts(header string) (oldStart, newStart int) {
+	fields := strings.Fields(header)
+	for _, f := range fields[1:] {
+		if strings.HasPrefix(f, "@") {
+			break
+		}
+		start, _, _ := strings.Cut(f[1:], ",")
+		n, _ := strconv.Atoi(start)
+		if f[0] == '-' && oldStart == 0 {
+			oldStart = n
+		} else if f[0] == '+' {
+			newStart = n
+		}
+	}
+	return oldStart, newStart
+}
+
+// segment is a piece of a line, changed when it differs from the line it
+// replaces
+type segment struct {
+	text    string
+	changed bool
+}
+
+// tokenize splits a line into words, runs of spaces and single symbols
+func tokenize(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 0
+		case unicode.IsSpace(r):
+			return 1
+		}
+		return 2
+	}
+	for i := 0; i < len(runes); {
+		j := i + 1
+		if c := class(runes[i]); c != 2 {
+			for j < len(runes) && class(runes[j]) == c {
+				j++
+			}
+		}
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+// wordDiff finds the words that differ between a removed line and the line
+// added in its place, keeping their common start and end. It returns nil
+// when the lines have nothing in common.
+func wordDiff(old, new string) (oldSegs, newSegs []segment) {
+	a, b := tokenize(old), tokenize(new)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if prefix == 0 && suffix == 0 {
+		return nil, nil
+	}
+
+	split := func(tokens []string) []segment {
+		end := len(tokens) - suffix
+		return []segment{
+			{text: strings.Join(tokens[:prefix], "")},
+			{text: strings.Join(tokens[prefix:end], ""), changed: true},
+			{text: strings.Join(tokens[end:], "")},
+		}
+	}
+	return split(a), split(b)
+}
+
+// wordChanges pairs each block of removed lines with the block added right
+// after it, line by line when both have as many, and diffs their words
+func wordChanges(lines []diffLine) map[int][]segment {
+	changes := map[int][]segment{}
+	for i := 0; i < len(lines); {
+		if lines[i].kind() != '-' {
+			i++
+			continue
+		}
+		dels := i
+		for i < len(lines) && lines[i].kind() == '-' {
+			i++
+		}
+		adds := i
+		for i < len(lines) && lines[i].kind() == '+' {
+			i++
+		}
+		if adds-dels != i-adds {
+			continue
+		}
+		for k := 0; k < adds-dels; k++ {
+			oldSegs, newSegs := wordDiff(lines[dels+k].text, lines[adds+k].text)
+			if oldSegs != nil {
+				changes[dels+k] = oldSegs
+				changes[adds+k] = newSegs
+			}
+		}
+	}
+	return changes
+}
+
+// hunkCode highlights the syntax of a hunk's lines, or returns nil for
+// languages that are not known. The old and new sides of the hunk are
+// highlighted separately, each as a whole, so strings and comments spanning
+// several lines are coloured right.
+func hunkCode(name string, h diffHunk) [][]span {
+	var oldText, newText []string
+	for _, l := range h.lines {
+		switch l.kind() {
+		case '-':
+			oldText = append(oldText, l.text)
+		case '+':
+			newText = append(newText, l.text)
+		case ' ':
+			oldText = append(oldText, l.text)
+			newText = append(newText, l.text)
+		}
+	}
+	oldCode := highlightSpans(name, strings.Join(oldText, "\n"))
+	newCode := highlightSpans(name, strings.Join(newText, "\n"))
+	if oldCode == nil || newCode == nil {
+		return nil
+	}
+
+	code := make([][]span, len(h.lines))
+	o, n := 0, 0
+	for i, l := range h.lines {
+		switch l.kind() {
+		case '-':
+			code[i] = codeLine(oldCode, o)
+			o++
+		case '+':
+			code[i] = codeLine(newCode, n)
+			n++
+		case ' ':
+			code[i] = codeLine(newCode, n)
+			o++
+			n++
+		}
+	}
+	return code
+}
+
+// fileCode highlights the syntax of each hunk of a file, leaving diffs too
+// large to highlight quickly plain
+func fileCode(f diffFile) [][][]span {
+	size := 0
+	for _, h := range f.hunks {
+		for _, l := range h.lines {
+			size += len(l.text)
+		}
+	}
+	if size > maxHighlightBytes {
+		return nil
+	}
+
+	code := make([][][]span, len(f.hunks))
+	for i, h := range f.hunks {
+		code[i] = hunkCode(f.name, h)
+	}
+	return code
+}
+
+// renderCode draws highlighted code of a line over the background of an
+// added or removed line, with a stronger one behind its changed words
+func renderCode(l diffLine, segs []segment, code []span) string {
+	var bg, wordBg lipgloss.TerminalColor
+	switch l.kind() {
+	case '+':
+		bg, wordBg = addBackground, addWordBackground
+	case '-':
+		bg, wordBg = delBackground, delWordBackground
+	}
+
+	// The changed words are the middle segment, as byte offsets in the line
+	start, end := 0, 0
+	if segs != nil {
+		start = len(segs[0].text)
+		end = start + len(segs[1].text)
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, s := range code {
+		for text := s.text; text != ""; {
+			n := len(text)
+			if pos < start {
+				n = min(n, start-pos)
+			} else if pos < end {
+				n = min(n, end-pos)
+			}
+
+			style := s.style
+			if pos >= start && pos < end {
+				style = style.Background(wordBg)
+			} else if bg != nil {
+				style = style.Background(bg)
+			}
+			b.WriteString(style.Render(text[:n]))
+			pos += n
+			text = text[n:]
+		}
+	}
+	return b.String()
+}
+
+// renderLine styles the text of a line, highlighting its changed words.
+// Lines of code in a known language are syntax highlighted, others are
+// coloured by whether they were added or removed.
+func renderLine(l diffLine, segs []segment, code []span) string {
+	if code != nil || l.text == "" {
+		joined := ""
+		for _, s := range code {
+			joined += s.text
+		}
+		if joined == l.text {
+			return renderCode(l, segs, code)
+		}
+	}
+
+	style, wordStyle := baseDiffStyle, baseDiffStyle
+	switch l.kind() {
+	case '+':
+		style, wordStyle = addStyle, addWordStyle
+	case '-':
+		style, wordStyle = delStyle, delWordStyle
+	}
+	if segs == nil {
+		return style.Render(l.text)
+	}
+
+	var b strings.Builder
+	for _, s := range segs {
+		if s.changed {
+			b.WriteString(wordStyle.Render(s.text))
+		} else {
+			b.WriteString(style.Render(s.text))
+		}
+	}
+	return b.String()
+}
+
+// diffView shows a diff file by file, with a summary of the files changed
+type diffView struct {
+	viewport   viewport.Model
+	preamble   []string
+	files      []diffFile
+	sideBySide bool
+	fileLines  []int // Line of the viewport where each file starts
+	hunkLines  []int // Line of the viewport where each hunk starts
+}
+
+func newDiffView(width, height int) diffView {
+	return diffView{viewport: viewport.New(width, height)}
+}
+
+// setDiff shows git's diff output
+func (d *diffView) setDiff(raw string) {
+	d.preamble, d.files = parseDiff(raw)
+	d.render()
+	d.viewport.GotoTop()
+}
+
+// setMessage shows text in place of a diff
+func (d *diffView) setMessage(text string) {
+	d.preamble, d.files = []string{text}, nil
+	d.render()
+	d.viewport.GotoTop()
+}
+
+func (d *diffView) setSize(width, height int) {
+	resized := d.viewport.Width != width
+	d.viewport.Width, d.viewport.Height = width, height
+	if resized {
+		d.render() // Side by side depends on the width
+	}
+}
+
+// render lays out the diff in the viewport
+func (d *diffView) render() {
+	d.fileLines, d.hunkLines = nil, nil
+	var lines []string
+	for _, line := range d.preamble {
+		lines = append(lines, baseDiffStyle.Render(line))
+	}
+
+	if len(d.files) > 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, d.summary()...)
+	}
+
+	split := d.sideBySide && d.viewport.Width >= minSideBySideWidth
+	for _, f := range d.files {
+		code := fileCode(f)
+		lines = append(lines, "")
+		d.fileLines = append(d.fileLines, len(lines))
+
+		header := fileHeaderStyle.Render(f.name)
+		if f.status != "" {
+			header += baseDiffStyle.Render(" (" + f.status + ")")
+		}
+		lines = append(lines, header)
+		if f.binary {
+			lines = append(lines, baseDiffStyle.Render("Binary file, not shown"))
+			continue
+		}
+
+		for i, h := range f.hunks {
+			var hunkCode [][]span
+			if code != nil {
+				hunkCode = code[i]
+			}
+			d.hunkLines = append(d.hunkLines, len(lines))
+			lines = append(lines, headerStyle.Render(h.header))
+			if split && f.parents == 1 {
+				lines = append(lines, d.renderSplit(h, hunkCode)...)
+			} else {
+				lines = append(lines, renderUnified(h, hunkCode)...)
+			}
+		}
+	}
+
+	d.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// summary lists the changed files with how many lines each added and removed
+func (d *diffView) summary() []string {
+	added, deleted := 0, 0
+	for _, f := range d.files {
+		added += f.added
+		deleted += f.deleted
+	}
+	files := "files"
+	if len(d.files) == 1 {
+		files = "file"
+	}
+	lines := []string{fmt.Sprintf("%s changed  %s %s",
+		fileHeaderStyle.Render(fmt.Sprintf("%d %s", len(d.files), files)),
+		addStyle.Render(fmt.Sprintf("+%d", added)),
+		delStyle.Render(fmt.Sprintf("-%d", deleted)))}
+
+	for _, f := range d.files {
+		stats := addStyle.Render(fmt.Sprintf("%6s", fmt.Sprintf("+%d", f.added))) + " " +
+			delStyle.Render(fmt.Sprintf("%-6s", fmt.Sprintf("-%d", f.deleted)))
+		if f.binary {
+			stats = baseDiffStyle.Render(fmt.Sprintf("%13s", "binary"))
+		}
+		lines = append(lines, stats+" "+f.name)
+	}
+	return lines
+}
+
+// renderUnified renders a hunk as git prints it, one column of +/- per parent
+func renderUnified(h diffHunk, code [][]span) []string {
+	changes := wordChanges(h.lines)
+	lines := make([]string, len(h.lines))
+	for i, l := range h.lines {
+		style := baseDiffStyle
+		switch l.kind() {
+		case '+':
+			style = addStyle
+		case '-':
+			style = delStyle
+		}
+		lines[i] = style.Render(l.prefix) + renderLine(l, changes[i], codeLine(code, i))
+	}
+	return lines
+}
+
+// renderSplit renders a hunk in two columns, the old file on the left and
+// the new one on the right, with removed and added lines facing each other
+func (d *diffView) renderSplit(h diffHunk, code [][]span) []string {
+	width := (d.viewport.Width - 3) / 2
+	changes := wordChanges(h.lines)
+	oldLine, newLine := h.oldStart, h.newStart
+
+	cell := func(number int, text string) string {
+		c := ansi.Truncate(baseDiffStyle.Render(fmt.Sprintf("%4d ", number))+text, width, "…")
+		return c + strings.Repeat(" ", max(width-ansi.StringWidth(c), 0))
+	}
+	blank := strings.Repeat(" ", width)
+	separator := baseDiffStyle.Render(" │ ")
+
+	var rows []string
+	for i := 0; i < len(h.lines); {
+		l := h.lines[i]
+		switch l.kind() {
+		case ' ':
+			text := renderLine(l, nil, codeLine(code, i))
+			rows = append(rows, cell(oldLine, text)+separator+cell(newLine, text))
+			oldLine++
+			newLine++
+			i++
+		case '\\':
+			rows = append(rows, baseDiffStyle.Render(l.prefix+l.text))
+			i++
+		default:
+			var left, right []string
+			for i < len(h.lines) && h.lines[i].kind() == '-' {
+				left = append(left, cell(oldLine, renderLine(h.lines[i], changes[i], codeLine(code, i))))
+				oldLine++
+				i++
+			}
+			for i < len(h.lines) && h.lines[i].kind() == '+' {
+				right = append(right, cell(newLine, renderLine(h.lines[i], changes[i], codeLine(code, i))))
+				newLine++
+				i++
+			}
+			for k := 0; k < max(len(left), len(right)); k++ {
+				row := blank
+				if k < len(left) {
+					row = left[k]
+				}
+				row += separator
+				if k < len(right) {
+					row += right[k]
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+// codeLine is the highlighted code of a line of a hunk, nil when the hunk
+// is not highlighted
+func codeLine(code [][]span, i int) []span {
+	if i < len(code) {
+		return code[i]
+	}
+	return nil
+}
+
+// jump scrolls to the next, or previous, of the given lines
+func (d *diffView) jump(lines []int, forward bool) {
+	y := d.viewport.YOffset
+	if forward {
+		for _, l := range lines {
+			if l > y {
+				d.viewport.SetYOffset(l)
+				return
+			}
+		}
+		return
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if lines[i] < y {
+			d.viewport.SetYOffset(lines[i])
+			return
+		}
+	}
+}
+
+func (d diffView) Update(msg tea.Msg) (diffView, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "n":
+			d.jump(d.fileLines, true)
+			return d, nil
+		case "p":
+			d.jump(d.fileLines, false)
+			return d, nil
+		case "]":
+			d.jump(d.hunkLines, true)
+			return d, nil
+		case "[":
+			d.jump(d.hunkLines, false)
+			return d, nil
+		case "v":
+			d.sideBySide = !d.sideBySide
+			d.render()
+			return d, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return d, cmd
+}
+
+func (d diffView) View() string {
+	return d.viewport.View()
+}
@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		preamble []string
+		files    []diffFile
+	}{
+		{
+			name: "modified file",
+			raw: "commit abc\nAuthor: A <a@b>\n\n    Fix it\n\n" +
+				"diff --git a/main.go b/main.go\n" +
+				"index 1111111..2222222 100644\n" +
+				"--- a/main.go\n" +
+				"+++ b/main.go\n" +
+				"@@ -1,3 +1,3 @@ package main\n" +
+				" func main() {\n" +
+				"-\tprintln(1)\n" +
+				"+\tprintln(2)\n" +
+				" }\n",
+			preamble: []string{"commit abc", "Author: A <a@b>", "", "    Fix it", ""},
+			files: []diffFile{{
+				name: "main.go", parents: 1, added: 1, deleted: 1,
+				hunks: []diffHunk{{
+					header: "@@ -1,3 +1,3 @@ package main", oldStart: 1, newStart: 1,
+					lines: []diffLine{
+						{prefix: " ", text: "func main() {"},
+						{prefix: "-", text: "    println(1)"},
+						{prefix: "+", text: "    println(2)"},
+						{prefix: " ", text: "}"},
+					},
+				}},
+			}},
+		},
+		{
+			name: "new file without trailing newline",
+			raw: "diff --git a/a.txt b/a.txt\n" +
+				"new file mode 100644\n" +
+				"--- /dev/null\n" +
+				"+++ b/a.txt\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+hello\n" +
+				"\\ No newline at end of file\n",
+			files: []diffFile{{
+				name: "a.txt", status: "new file", parents: 1, added: 1,
+				hunks: []diffHunk{{
+					header: "@@ -0,0 +1 @@", oldStart: 0, newStart: 1,
+					lines: []diffLine{
+						{prefix: "+", text: "hello"},
+						{prefix: "\\", text: " No newline at end of file"},
+					},
+				}},
+			}},
+		},
+		{
+			name: "deleted file",
+			raw: "diff --git a/old.txt b/old.txt\n" +
+				"deleted file mode 100644\n" +
+				"--- a/old.txt\n" +
+				"+++ /dev/null\n" +
+				"@@ -1,2 +0,0 @@\n" +
+				"-one\n" +
+				"-two\n",
+			files: []diffFile{{
+				name: "old.txt", status: "deleted", parents: 1, deleted: 2,
+				hunks: []diffHunk{{
+					header: "@@ -1,2 +0,0 @@", oldStart: 1, newStart: 0,
+					lines: []diffLine{{prefix: "-", text: "one"}, {prefix: "-", text: "two"}},
+				}},
+			}},
+		},
+		{
+			name: "pure rename and binary file",
+			raw: "diff --git a/docs/old.md b/docs/new.md\n" +
+				"similarity index 100%\n" +
+				"rename from docs/old.md\n" +
+				"rename to docs/new.md\n" +
+				"diff --git a/logo.png b/logo.png\n" +
+				"index 1111111..2222222 100644\n" +
+				"Binary files a/logo.png and b/logo.png differ\n",
+			files: []diffFile{
+				{name: "docs/new.md", status: "renamed from docs/old.md", parents: 1},
+				{name: "logo.png", binary: true, parents: 1},
+			},
+		},
+		{
+			name: "combined merge diff",
+			raw: "diff --cc conflict.txt\n" +
+				"index 1111111,2222222..3333333\n" +
+				"--- a/conflict.txt\n" +
+				"+++ b/conflict.txt\n" +
+				"@@@ -1,1 -1,1 +1,1 @@@\n" +
+				"- ours\n" +
+				" -theirs\n" +
+				"++merged\n",
+			files: []diffFile{{
+				name: "conflict.txt", parents: 2, added: 1, deleted: 2,
+				hunks: []diffHunk{{
+					header: "@@@ -1,1 -1,1 +1,1 @@@", oldStart: 1, newStart: 1,
+					lines: []diffLine{
+						{prefix: "- ", text: "ours"},
+						{prefix: " -", text: "theirs"},
+						{prefix: "++", text: "merged"},
+					},
+				}},
+			}},
+		},
+		{
+			name:     "no files",
+			raw:      "commit abc\n\n    Empty\n",
+			preamble: []string{"commit abc", "", "    Empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preamble, files := parseDiff(tt.raw)
+			if !reflect.DeepEqual(preamble, tt.preamble) {
+				t.Errorf("preamble = %q, want %q", preamble, tt.preamble)
+			}
+			if !reflect.DeepEqual(files, tt.files) {
+				t.Errorf("files =\n%+v\nwant\n%+v", files, tt.files)
+			}
+		})
+	}
+}
+
+func TestDiffGitName(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "diff --git a/main.go b/main.go", want: "main.go"},
+		{line: "diff --git a/pkg/a b/pkg/a", want: "pkg/a"},
+		{line: "diff --git a/with space.txt b/with space.txt", want: "with space.txt"},
+		{line: "diff --cc merged.go", want: "merged.go"},
+		{line: "diff --combined merged.go", want: "merged.go"},
+	}
+
+	for _, tt := range tests {
+		if got := diffGitName(tt.line); got != tt.want {
+			t.Errorf("diffGitName(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestHunkStarts(t *testing.T) {
+	tests := []struct {
+		header   string
+		old, new int
+	}{
+		{header: "@@ -1,2 +3,4 @@", old: 1, new: 3},
+		{header: "@@ -10 +12 @@ func main() {", old: 10, new: 12},
+		{header: "@@ -0,0 +1,5 @@", old: 0, new: 1},
+		{header: "@@ -7,3 +0,0 @@", old: 7, new: 0},
+		{header: "@@@ -5,2 -6,2 +9,3 @@@", old: 5, new: 9},
+		{header: "@@ -1 +1 @@ x := a - b + c", old: 1, new: 1},
+	}
+
+	for _, tt := range tests {
+		if old, new := hunkStarts(tt.header); old != tt.old || new != tt.new {
+			t.Errorf("hunkStarts(%q) = %d, %d, want %d, %d", tt.header, old, new, tt.old, tt.new)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "", want: nil},
+		{in: "foo_bar1 := baz(x)", want: []string{"foo_bar1", " ", ":", "=", " ", "baz", "(", "x", ")"}},
+		{in: "a  b", want: []string{"a", "  ", "b"}},
+		{in: "héllo wörld", want: []string{"héllo", " ", "wörld"}},
+	}
+
+	for _, tt := range tests {
+		if got := tokenize(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		oldSegs  []segment
+		newSegs  []segment
+	}{
+		{
+			name:    "changed word in the middle",
+			old:     "x := foo(1)",
+			new:     "x := bar(1)",
+			oldSegs: []segment{{text: "x := "}, {text: "foo", changed: true}, {text: "(1)"}},
+			newSegs: []segment{{text: "x := "}, {text: "bar", changed: true}, {text: "(1)"}},
+		},
+		{
+			name:    "words appended",
+			old:     "return nil",
+			new:     "return nil, err",
+			oldSegs: []segment{{text: "return nil"}, {text: "", changed: true}, {text: ""}},
+			newSegs: []segment{{text: "return nil"}, {text: ", err", changed: true}, {text: ""}},
+		},
+		{
+			name:    "common end only",
+			old:     "a = 1;",
+			new:     "b = 2;",
+			oldSegs: []segment{{text: ""}, {text: "a = 1", changed: true}, {text: ";"}},
+			newSegs: []segment{{text: ""}, {text: "b = 2", changed: true}, {text: ";"}},
+		},
+		{name: "nothing in common", old: "foo", new: "bar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldSegs, newSegs := wordDiff(tt.old, tt.new)
+			if !reflect.DeepEqual(oldSegs, tt.oldSegs) || !reflect.DeepEqual(newSegs, tt.newSegs) {
+				t.Errorf("wordDiff(%q, %q) =\n%+v\n%+v\nwant\n%+v\n%+v", tt.old, tt.new, oldSegs, newSegs, tt.oldSegs, tt.newSegs)
+			}
+		})
+	}
+}
+
+func TestWordChanges(t *testing.T) {
+	line := func(prefix, text string) diffLine { return diffLine{prefix: prefix, text: text} }
+
+	tests := []struct {
+		name    string
+		lines   []diffLine
+		changed []int // Lines given word segments
+	}{
+		{
+			name:    "one line replaced",
+			lines:   []diffLine{line(" ", "a"), line("-", "x = 1"), line("+", "x = 2"), line(" ", "b")},
+			changed: []int{1, 2},
+		},
+		{
+			name:    "blocks of the same size pair up line by line",
+			lines:   []diffLine{line("-", "x = 1"), line("-", "y = 1"), line("+", "x = 2"), line("+", "y = 2")},
+			changed: []int{0, 1, 2, 3},
+		},
+		{
+			name:  "blocks of different sizes are left alone",
+			lines: []diffLine{line("-", "x = 1"), line("+", "x = 2"), line("+", "y = 2")},
+		},
+		{
+			name:  "lines with nothing in common are left alone",
+			lines: []diffLine{line("-", "foo"), line("+", "bar")},
+		},
+		{
+			name:  "additions alone",
+			lines: []diffLine{line("+", "x = 1"), line("+", "x = 2")},
+		},
+		{
+			name:    "separate blocks",
+			lines:   []diffLine{line("-", "x = 1"), line("+", "x = 2"), line(" ", "ctx"), line("-", "y = 1"), line("+", "y = 3")},
+			changed: []int{0, 1, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := wordChanges(tt.lines)
+			var got []int
+			for i := range tt.lines {
+				if _, ok := changes[i]; ok {
+					got = append(got, i)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.changed) {
+				t.Errorf("lines with word changes = %v, want %v", got, tt.changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,545 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/nim-sam/gitport/pkg/maintenance"
+)
+
+// maxViewBytes is how much of a file the Files tab shows
+const maxViewBytes = 1024 * 1024
+
+// fileEntry is a file or directory of the tree being browsed
+type fileEntry struct {
+	name string
+	dir  bool
+	blob plumbing.Hash // Contents of a file in the tree, zero when unknown
+}
+
+func (e fileEntry) FilterValue() string { return e.name }
+
+// fileInfoMsg carries the last commit that touched a file
+type fileInfoMsg struct {
+	ref  refOption
+	path string
+	info string
+}
+
+// fileHistoryMsg asks the Commit History tab for the commits touching a path
+type fileHistoryMsg struct {
+	ref  refOption
+	path string
+}
+
+type filesModel struct {
+	repo     *git.Repository
+	repoPath string
+	ref      refOption
+	dir      string // Directory being listed, "" for the root
+	entries  list.Model
+	sizes    *blobSizes
+	err      string
+
+	// The file being viewed, if any
+	viewing  bool
+	file     string
+	info     string
+	viewport viewport.Model
+
+	picking bool // The ref picker is open
+	picker  list.Model
+
+	blaming  bool // The blame view is open
+	blame    blameModel
+	choosing bool // Picking which file of a commit to blame
+	chooser  list.Model
+	chosen   plumbing.Hash // Commit whose files are being picked from
+
+	width  int
+	height int
+}
+
+// newFiles browses the tree of a ref, starting at its root
+func newFiles(repo *git.Repository, repoPath string, ref refOption) filesModel {
+	sizes := &blobSizes{repo: repo, sizes: make(map[plumbing.Hash]int64)}
+	l := list.New(nil, fileDelegate{sizes: sizes}, 0, 0)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.KeyMap.Quit.SetEnabled(false)
+	// The arrows, h, l and b open entries, show history and switch ref rather than paging
+	l.KeyMap.NextPage.SetKeys("pgdown", "f", "d")
+	l.KeyMap.PrevPage.SetKeys("pgup", "u")
+
+	m := filesModel{repo: repo, repoPath: repoPath, ref: ref, entries: l, sizes: sizes, viewport: viewport.New(0, 0)}
+	m.listDir("")
+	return m
+}
+
+// blobSizes looks up the sizes of files as their rows are drawn, so listing
+// a large directory doesn't read every file in it
+type blobSizes struct {
+	repo  *git.Repository
+	sizes map[plumbing.Hash]int64
+}
+
+// size returns the size of a blob, -1 if it can't be read
+func (s *blobSizes) size(hash plumbing.Hash) int64 {
+	if size, ok := s.sizes[hash]; ok {
+		return size
+	}
+	size := int64(-1)
+	if blob, err := s.repo.BlobObject(hash); err == nil {
+		size = blob.Size
+	}
+	s.sizes[hash] = size
+	return size
+}
+
+// tree returns the tree of the current ref at a directory
+func (m filesModel) tree(dir string) (*object.Tree, error) {
+	tips := refTips(m.repo, m.ref)
+	if len(tips) == 0 {
+		return nil, fmt.Errorf("%s has no commits", m.ref.Label())
+	}
+	tree, err := tips[0].Tree()
+	if err != nil || dir == "" {
+		return tree, err
+	}
+	return tree.Tree(dir)
+}
+
+// listDir shows the contents of a directory, directories first
+func (m *filesModel) listDir(dir string) {
+	m.err = ""
+	tree, err := m.tree(dir)
+	if err != nil {
+		m.err = err.Error()
+		m.entries.SetItems(nil)
+		return
+	}
+
+	var dirs, files []list.Item
+	for _, entry := range tree.Entries {
+		switch entry.Mode {
+		case filemode.Dir:
+			dirs = append(dirs, fileEntry{name: entry.Name, dir: true})
+		case filemode.Submodule:
+			// Submodule commits aren't stored in this repository
+		default:
+			files = append(files, fileEntry{name: entry.Name, blob: entry.Hash})
+		}
+	}
+	byName := func(items []list.Item) {
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].(fileEntry).name < items[j].(fileEntry).name
+		})
+	}
+	byName(dirs)
+	byName(files)
+
+	m.dir = dir
+	m.entries.ResetFilter()
+	m.entries.SetItems(append(dirs, files...))
+	m.entries.Select(0)
+}
+
+// openFile shows a file's contents and looks up its last commit
+func (m *filesModel) openFile(filePath string) tea.Cmd {
+	tree, err := m.tree("")
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	file, err := tree.File(filePath)
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+
+	m.viewing = true
+	m.file = filePath
+	m.info = maintenance.FormatSize(file.Size)
+	m.viewport.SetContent(m.renderFile(file))
+	m.viewport.GotoTop()
+	return lastCommit(m.repoPath, m.ref, filePath)
+}
+
+// renderFile numbers and highlights a file's lines
+func (m filesModel) renderFile(file *object.File) string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	reader, err := file.Reader()
+	if err != nil {
+		return "Could not read file: " + err.Error()
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, maxViewBytes))
+	if err != nil {
+		return "Could not read file: " + err.Error()
+	}
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+		return dim.Render(fmt.Sprintf("Binary file, %s", maintenance.FormatSize(file.Size)))
+	}
+
+	lines := highlightLines(file.Name, strings.TrimSuffix(string(content), "\n"))
+	width := len(fmt.Sprint(len(lines)))
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(dim.Render(fmt.Sprintf("%*d ", width, i+1)))
+		b.WriteString(strings.ReplaceAll(line, "\t", "    "))
+		b.WriteString("\n")
+	}
+	if file.Size > maxViewBytes {
+		b.WriteString(dim.Render(fmt.Sprintf("... showing the first %s of %s", maintenance.FormatSize(maxViewBytes), maintenance.FormatSize(file.Size))))
+	}
+	return b.String()
+}
+
+// lastCommit finds the newest commit of a ref that touched a file, with a
+// repository of its own as the TUI keeps using its one
+func lastCommit(repoPath string, ref refOption, filePath string) tea.Cmd {
+	return func() tea.Msg {
+		msg := fileInfoMsg{ref: ref, path: filePath}
+		repo, err := git.PlainOpen(repoPath)
+		if err != nil {
+			return msg
+		}
+		tips := refTips(repo, ref)
+		if len(tips) == 0 {
+			return msg
+		}
+		iter, err := repo.Log(&git.LogOptions{From: tips[0].Hash, FileName: &filePath})
+		if err != nil {
+			return msg
+		}
+		defer iter.Close()
+
+		if c, err := iter.Next(); err == nil {
+			subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+			msg.info = fmt.Sprintf("%s %s, %s %s", c.Hash.String()[:7], subject, c.Author.Name, c.Author.When.Format("Jan 02, 2006"))
+		}
+		return msg
+	}
+}
+
+func (m filesModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m filesModel) Update(msg tea.Msg) (filesModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		// Leave room for the path header and help line
+		m.entries.SetSize(msg.Width, max(msg.Height-2, 1))
+		m.viewport.Width = msg.Width
+		m.viewport.Height = max(msg.Height-2, 1)
+		m.blame, _ = m.blame.Update(tea.WindowSizeMsg{Width: msg.Width, Height: max(msg.Height-2, 1)})
+		if m.picking {
+			m.picker.SetSize(msg.Width, msg.Height-1)
+		}
+		if m.choosing {
+			m.chooser.SetSize(msg.Width, msg.Height-1)
+		}
+		return m, nil
+
+	case fileInfoMsg:
+		if m.viewing && msg.path == m.file && msg.ref == m.ref && msg.info != "" {
+			m.info += "  " + msg.info
+		}
+		return m, nil
+
+	case blameMsg:
+		m.blame, _ = m.blame.Update(msg)
+		return m, nil
+	}
+
+	if m.picking {
+		return m.updatePicker(msg)
+	}
+	if m.choosing {
+		return m.updateChooser(msg)
+	}
+	if m.blaming {
+		if key, ok := msg.(tea.KeyMsg); ok && (key.String() == "esc" || key.String() == "backspace") {
+			m.blaming = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.blame, cmd = m.blame.Update(msg)
+		return m, cmd
+	}
+	if m.viewing {
+		return m.updateFile(msg)
+	}
+
+	if key, ok := msg.(tea.KeyMsg); ok && m.entries.FilterState() != list.Filtering {
+		switch key.String() {
+		case "enter", "right", "l":
+			entry, ok := m.entries.SelectedItem().(fileEntry)
+			if !ok {
+				return m, nil
+			}
+			if entry.dir {
+				m.listDir(path.Join(m.dir, entry.name))
+				return m, nil
+			}
+			return m, m.openFile(path.Join(m.dir, entry.name))
+		case "backspace", "left":
+			if m.dir != "" {
+				m.listDir(parentDir(m.dir))
+			}
+			return m, nil
+		case "h":
+			target := m.dir
+			if entry, ok := m.entries.SelectedItem().(fileEntry); ok {
+				target = path.Join(m.dir, entry.name)
+			}
+			return m, showHistory(m.ref, target)
+		case "a":
+			if entry, ok := m.entries.SelectedItem().(fileEntry); ok && !entry.dir {
+				return m, m.blameAtRef(path.Join(m.dir, entry.name))
+			}
+			return m, nil
+		case "b":
+			refs := listRefs(m.repo)[1:] // A tree belongs to a single ref
+			m.picking = true
+			m.picker = newRefPicker(refs, m.width, m.height-1)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.entries, cmd = m.entries.Update(msg)
+	return m, cmd
+}
+
+// updateFile scrolls the file being viewed
+func (m filesModel) updateFile(msg tea.Msg) (filesModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc", "left", "backspace":
+			m.viewing = false
+			return m, nil
+		case "h":
+			return m, showHistory(m.ref, m.file)
+		case "a":
+			return m, m.blameAtRef(m.file)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// updatePicker switches the ref being browsed, keeping the directory if it still exists
+func (m filesModel) updatePicker(msg tea.Msg) (filesModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.picker.FilterState() != list.Filtering {
+		switch key.String() {
+		case "esc":
+			if m.picker.FilterState() == list.Unfiltered {
+				m.picking = false
+				return m, nil
+			}
+		case "enter":
+			if opt, ok := m.picker.SelectedItem().(refOption); ok {
+				m.picking = false
+				m.ref = opt
+				m.listDir(m.dir)
+				if m.err != "" && m.dir != "" {
+					m.listDir("")
+				}
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	return m, cmd
+}
+
+// blameAtRef opens the blame of a file as of the ref being browsed
+func (m *filesModel) blameAtRef(filePath string) tea.Cmd {
+	tips := refTips(m.repo, m.ref)
+	if len(tips) == 0 {
+		return nil
+	}
+	return m.openBlame(tips[0].Hash, filePath)
+}
+
+// openBlame shows the blame of a file as of a commit
+func (m *filesModel) openBlame(commit plumbing.Hash, filePath string) tea.Cmd {
+	var cmd tea.Cmd
+	m.blaming = true
+	m.blame, cmd = newBlame(m.repoPath, commit, filePath, m.width, max(m.height-2, 1))
+	return cmd
+}
+
+// requestBlame blames a file changed by a commit, asking which one when there are several
+func (m *filesModel) requestBlame(msg blameRequestMsg) tea.Cmd {
+	switch len(msg.files) {
+	case 0:
+		m.err = "The commit only deleted files, there is nothing to blame"
+		return nil
+	case 1:
+		return m.openBlame(msg.commit, msg.files[0])
+	}
+
+	items := make([]list.Item, len(msg.files))
+	for i, f := range msg.files {
+		items[i] = fileEntry{name: f}
+	}
+	m.chooser = list.New(items, fileDelegate{}, m.width, m.height-1)
+	m.chooser.Title = "Blame which file of " + msg.commit.String()[:7] + "?"
+	m.chooser.SetShowStatusBar(false)
+	m.chooser.SetShowHelp(false)
+	m.chooser.KeyMap.Quit.SetEnabled(false)
+	m.chosen = msg.commit
+	m.choosing = true
+	return nil
+}
+
+// updateChooser picks the file of a commit to blame
+func (m filesModel) updateChooser(msg tea.Msg) (filesModel, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.chooser.FilterState() != list.Filtering {
+		switch key.String() {
+		case "esc":
+			if m.chooser.FilterState() == list.Unfiltered {
+				m.choosing = false
+				return m, nil
+			}
+		case "enter":
+			m.choosing = false
+			if entry, ok := m.chooser.SelectedItem().(fileEntry); ok {
+				return m, m.openBlame(m.chosen, entry.name)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.chooser, cmd = m.chooser.Update(msg)
+	return m, cmd
+}
+
+// showHistory switches to Commit History, listing the commits touching a path
+func showHistory(ref refOption, filePath string) tea.Cmd {
+	return func() tea.Msg {
+		return fileHistoryMsg{ref: ref, path: filePath}
+	}
+}
+
+// parentDir is the directory containing dir, "" at the root
+func parentDir(dir string) string {
+	parent := path.Dir(dir)
+	if parent == "." {
+		return ""
+	}
+	return parent
+}
+
+func (m filesModel) View() string {
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	pathStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+
+	if m.picking {
+		help := helpStyle.Render("[up/down] Select  [/] Filter  [enter] Browse  [esc] Cancel")
+		return lipgloss.JoinVertical(lipgloss.Left, m.picker.View(), help)
+	}
+
+	if m.choosing {
+		help := helpStyle.Render("[up/down] Select  [/] Filter  [enter] Blame  [esc] Cancel")
+		return lipgloss.JoinVertical(lipgloss.Left, m.chooser.View(), help)
+	}
+
+	if m.blaming {
+		header := pathStyle.Render(m.blame.path) + "  " + dim.Render("blame at "+m.blame.commit.String()[:7])
+		help := helpStyle.Render("[up/down] Select line  [enter] Show commit  [esc] Back  [tab] Switch tab")
+		return lipgloss.JoinVertical(lipgloss.Left, header, m.blame.View(), help)
+	}
+
+	refLabel := branchStyle.Render(m.ref.Label())
+	if m.ref.name.IsTag() {
+		refLabel = tagStyle.Render(m.ref.Label())
+	}
+
+	if m.viewing {
+		header := refLabel + " " + pathStyle.Render(m.file) + "  " + dim.Render(m.info)
+		help := helpStyle.Render("[up/down] Scroll  [h] File history  [a] Blame  [esc] Back to files  [tab] Switch tab")
+		return lipgloss.JoinVertical(lipgloss.Left, header, m.viewport.View(), help)
+	}
+
+	header := refLabel + " " + pathStyle.Render("/"+m.dir)
+	body := m.entries.View()
+	if m.err != "" {
+		body = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Render(m.err)
+	} else if len(m.entries.Items()) == 0 {
+		body = dim.Render("This directory is empty")
+	}
+	help := helpStyle.Render("[enter] Open  [backspace] Up  [h] History  [a] Blame  [b] Switch ref  [/] Filter  [tab] Switch tab")
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, help)
+}
+
+// fileDelegate draws entries, with their size when sizes is set
+type fileDelegate struct {
+	sizes *blobSizes
+}
+
+func (d fileDelegate) Height() int                               { return 1 }
+func (d fileDelegate) Spacing() int                              { return 0 }
+func (d fileDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d fileDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	e, ok := listItem.(fileEntry)
+	if !ok {
+		return
+	}
+
+	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true)
+	sizeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	cursor := "  "
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true).Render("> ")
+	}
+
+	if e.dir {
+		fmt.Fprint(w, cursor+dirStyle.Render(e.name+"/"))
+		return
+	}
+	name := e.name
+	if index == m.Index() {
+		name = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true).Render(name)
+	}
+	size := int64(-1)
+	if d.sizes != nil && !e.blob.IsZero() {
+		size = d.sizes.size(e.blob)
+	}
+	if size < 0 {
+		// Files picked from a commit have no size to show
+		fmt.Fprint(w, cursor+name)
+		return
+	}
+	fmt.Fprint(w, cursor+name+"  "+sizeStyle.Render(maintenance.FormatSize(size)))
+}
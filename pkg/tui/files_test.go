@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestFilesSizesAreLazy(t *testing.T) {
+	dir := t.TempDir()
+	when := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	gitIn(t, dir, when, "init", "--quiet", "--initial-branch=main")
+	for _, name := range []string{"b.txt", "a.txt", "docs/guide.md"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", 2048)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitIn(t, dir, when, "add", ".")
+	gitIn(t, dir, when, "commit", "--quiet", "-m", "files")
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newFiles(repo, dir, refOption{name: plumbing.NewBranchReferenceName("main")})
+
+	var names []string
+	for _, item := range m.entries.Items() {
+		names = append(names, item.(fileEntry).name)
+	}
+	if got := strings.Join(names, " "); got != "docs a.txt b.txt" {
+		t.Errorf("entries = %s, want directories first, then files by name", got)
+	}
+	if len(m.sizes.sizes) != 0 {
+		t.Fatalf("listing read %d blobs, want none until rows are drawn", len(m.sizes.sizes))
+	}
+
+	// Drawing a row reads its file's size, once
+	var row bytes.Buffer
+	for range 2 {
+		row.Reset()
+		fileDelegate{sizes: m.sizes}.Render(&row, m.entries, 1, m.entries.Items()[1])
+	}
+	if !strings.Contains(row.String(), "2.0 KiB") {
+		t.Errorf("row = %q, want the file's size", row.String())
+	}
+	if len(m.sizes.sizes) != 1 {
+		t.Errorf("drawing one row read %d blobs, want 1", len(m.sizes.sizes))
+	}
+}
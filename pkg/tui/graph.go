@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// graphColors are given in turn to new lanes of the commit graph
+var graphColors = []lipgloss.Color{"#6AB547", "#FFA500", "#00FFFF", "#FF6AD5", "#5C9DFF", "#FFE66D"}
+
+// commitGraph draws the lines linking commits to their parents, like
+// git log --graph, one commit at a time as the history is paged in
+type commitGraph struct {
+	lanes  []plumbing.Hash // Commit each lane leads down to, zero when free
+	colors []int
+	next   int // Colour of the next lane started
+}
+
+// graphCell is a lane of a graph row, and the line joining it to the next
+// lane on its right
+type graphCell struct {
+	symbol, joint     string
+	color, jointColor int
+}
+
+// rows draws the graph beside a commit: the row of the commit itself, the
+// row branching to its parents, and a row of the lanes carrying on below
+func (g *commitGraph) rows(c *object.Commit) []string {
+	col := g.lane(c.Hash)
+	if col < 0 {
+		// A branch tip no commit so far leads to
+		col = g.free(-1)
+		g.lanes[col] = c.Hash
+		g.colors[col] = g.newColor()
+	}
+
+	// The commit's row, where lanes of its other children end in it
+	above := g.cells()
+	above[col].symbol = "●"
+	for i, h := range g.lanes {
+		if i == col || h != c.Hash {
+			continue
+		}
+		above[i].symbol = "╰"
+		if i > col {
+			above[i].symbol = "╯"
+		}
+		g.join(above, col, i, g.colors[i])
+		g.lanes[i] = plumbing.ZeroHash
+	}
+
+	// The first parent carries on in the commit's lane, the others of a
+	// merge get a lane of their own unless one already leads to them
+	g.lanes[col] = plumbing.ZeroHash
+	if len(c.ParentHashes) > 0 {
+		g.lanes[col] = c.ParentHashes[0]
+	}
+	type branch struct {
+		lane  int
+		isNew bool
+	}
+	var branches []branch
+	for _, p := range c.ParentHashes[min(1, len(c.ParentHashes)):] {
+		if j := g.lane(p); j >= 0 && j != col {
+			branches = append(branches, branch{lane: j})
+			continue
+		}
+		j := g.free(col)
+		g.lanes[j] = p
+		g.colors[j] = g.newColor()
+		branches = append(branches, branch{lane: j, isNew: true})
+	}
+
+	below := g.cells()
+	left, right := false, false
+	for _, b := range branches {
+		switch {
+		case b.isNew && b.lane > col:
+			below[b.lane].symbol = "╮"
+		case b.isNew:
+			below[b.lane].symbol = "╭"
+		case b.lane > col:
+			below[b.lane].symbol = "┤"
+		default:
+			below[b.lane].symbol = "├"
+		}
+		left = left || b.lane < col
+		right = right || b.lane > col
+		g.join(below, col, b.lane, g.colors[b.lane])
+	}
+	switch {
+	case g.lanes[col].IsZero():
+		if left || right {
+			below[col].symbol = "─"
+		}
+	case left && right:
+		below[col].symbol = "┼"
+	case right:
+		below[col].symbol = "├"
+	case left:
+		below[col].symbol = "┤"
+	}
+
+	after := g.cells()
+	for len(g.lanes) > 0 && g.lanes[len(g.lanes)-1].IsZero() {
+		g.lanes = g.lanes[:len(g.lanes)-1]
+		g.colors = g.colors[:len(g.colors)-1]
+	}
+
+	// Rows are padded to the same width so the commit's text lines up
+	width := max(len(above), len(below), len(after))
+	return []string{renderCells(above, width), renderCells(below, width), renderCells(after, width)}
+}
+
+// lane is the lane leading to a commit, -1 when none does
+func (g *commitGraph) lane(h plumbing.Hash) int {
+	for i, l := range g.lanes {
+		if l == h {
+			return i
+		}
+	}
+	return -1
+}
+
+// free finds a free lane other than skip, adding one when all are taken
+func (g *commitGraph) free(skip int) int {
+	for i, l := range g.lanes {
+		if l.IsZero() && i != skip {
+			return i
+		}
+	}
+	g.lanes = append(g.lanes, plumbing.ZeroHash)
+	g.colors = append(g.colors, 0)
+	return len(g.lanes) - 1
+}
+
+func (g *commitGraph) newColor() int {
+	c := g.next
+	g.next = (g.next + 1) % len(graphColors)
+	return c
+}
+
+// cells draws the lanes currently in use as straight lines
+func (g *commitGraph) cells() []graphCell {
+	cells := make([]graphCell, len(g.lanes))
+	for i, h := range g.lanes {
+		cells[i] = graphCell{symbol: " ", joint: " ", color: g.colors[i]}
+		if !h.IsZero() {
+			cells[i].symbol = "│"
+		}
+	}
+	return cells
+}
+
+// join draws a horizontal line between two lanes of a row
+func (g *commitGraph) join(cells []graphCell, from, to, color int) {
+	for i := min(from, to); i < max(from, to); i++ {
+		cells[i].joint = "─"
+		cells[i].jointColor = color
+	}
+	cells[to].color = color
+}
+
+// renderCells colours a graph row, padded to width lanes
+func renderCells(cells []graphCell, width int) string {
+	var b strings.Builder
+	for _, c := range cells {
+		b.WriteString(lipgloss.NewStyle().Foreground(graphColors[c.color]).Render(c.symbol))
+		b.WriteString(lipgloss.NewStyle().Foreground(graphColors[c.jointColor]).Render(c.joint))
+	}
+	b.WriteString(strings.Repeat("  ", width-len(cells)))
+	return b.String()
+}
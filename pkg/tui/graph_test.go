@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestCommitGraphRows(t *testing.T) {
+	hash := func(name string) plumbing.Hash {
+		var h plumbing.Hash
+		copy(h[:], name)
+		return h
+	}
+	type commit struct {
+		name    string
+		parents []string
+		rows    []string // The commit's rows without their colours
+		lanes   int      // Lanes in use after it
+	}
+
+	tests := []struct {
+		name    string
+		commits []commit
+	}{
+		{
+			name: "linear",
+			commits: []commit{
+				{name: "c", parents: []string{"b"}, rows: []string{"● ", "│ ", "│ "}, lanes: 1},
+				{name: "b", parents: []string{"a"}, rows: []string{"● ", "│ ", "│ "}, lanes: 1},
+				{name: "a", rows: []string{"● ", "  ", "  "}, lanes: 0},
+			},
+		},
+		{
+			name: "merge",
+			commits: []commit{
+				{name: "m", parents: []string{"a", "b"}, rows: []string{"●   ", "├─╮ ", "│ │ "}, lanes: 2},
+				{name: "a", parents: []string{"base"}, rows: []string{"● │ ", "│ │ ", "│ │ "}, lanes: 2},
+				{name: "b", parents: []string{"base"}, rows: []string{"│ ● ", "│ │ ", "│ │ "}, lanes: 2},
+				{name: "base", rows: []string{"●─╯ ", "    ", "    "}, lanes: 0},
+			},
+		},
+		{
+			name: "merge of a commit already in a lane",
+			commits: []commit{
+				{name: "f", parents: []string{"base"}, rows: []string{"● ", "│ ", "│ "}, lanes: 1},
+				{name: "m", parents: []string{"a", "base"}, rows: []string{"│ ● ", "├─┤ ", "│ │ "}, lanes: 2},
+				{name: "a", parents: []string{"base"}, rows: []string{"│ ● ", "│ │ ", "│ │ "}, lanes: 2},
+				{name: "base", rows: []string{"●─╯ ", "    ", "    "}, lanes: 0},
+			},
+		},
+		{
+			name: "fork",
+			commits: []commit{
+				{name: "main", parents: []string{"base"}, rows: []string{"● ", "│ ", "│ "}, lanes: 1},
+				{name: "feature", parents: []string{"base"}, rows: []string{"│ ● ", "│ │ ", "│ │ "}, lanes: 2},
+				{name: "base", rows: []string{"●─╯ ", "    ", "    "}, lanes: 0},
+			},
+		},
+		{
+			name: "trailing lanes are trimmed",
+			commits: []commit{
+				{name: "a", parents: []string{"base"}, rows: []string{"● ", "│ ", "│ "}, lanes: 1},
+				{name: "b", parents: []string{"root"}, rows: []string{"│ ● ", "│ │ ", "│ │ "}, lanes: 2},
+				{name: "root", rows: []string{"│ ● ", "│   ", "│   "}, lanes: 1},
+				{name: "base", rows: []string{"● ", "  ", "  "}, lanes: 0},
+			},
+		},
+		{
+			name: "freed lanes are reused",
+			commits: []commit{
+				{name: "a", parents: []string{"base"}, rows: []string{"● ", "│ ", "│ "}, lanes: 1},
+				{name: "b", parents: []string{"root"}, rows: []string{"│ ● ", "│ │ ", "│ │ "}, lanes: 2},
+				{name: "c", parents: []string{"base"}, rows: []string{"│ │ ● ", "│ │ │ ", "│ │ │ "}, lanes: 3},
+				{name: "root", rows: []string{"│ ● │ ", "│   │ ", "│   │ "}, lanes: 3},
+				{name: "d", parents: []string{"base"}, rows: []string{"│ ● │ ", "│ │ │ ", "│ │ │ "}, lanes: 3},
+				{name: "base", rows: []string{"●─╯─╯ ", "      ", "      "}, lanes: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g commitGraph
+			for _, c := range tt.commits {
+				commit := &object.Commit{Hash: hash(c.name)}
+				for _, p := range c.parents {
+					commit.ParentHashes = append(commit.ParentHashes, hash(p))
+				}
+				var rows []string
+				for _, row := range g.rows(commit) {
+					rows = append(rows, ansi.Strip(row))
+				}
+				if strings.Join(rows, "|") != strings.Join(c.rows, "|") {
+					t.Errorf("%s rows = %q, want %q", c.name, rows, c.rows)
+				}
+				if len(g.lanes) != c.lanes {
+					t.Errorf("%s leaves %d lanes, want %d", c.name, len(g.lanes), c.lanes)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nim-sam/gitport/pkg/maintenance"
+)
+
+// healthTickMsg refreshes the Health tab from the maintenance state
+type healthTickMsg time.Time
+
+// healthDoneMsg is sent when a manual maintenance run finishes
+type healthDoneMsg maintenance.Result
+
+type healthModel struct {
+	last    maintenance.Result
+	hasRun  bool
+	history []maintenance.Result
+	running bool
+	width   int
+	height  int
+}
+
+func newHealth() healthModel {
+	m := healthModel{}
+	m.refresh()
+	return m
+}
+
+func (m *healthModel) refresh() {
+	m.last, m.hasRun = maintenance.LastResult()
+	m.history = maintenance.History()
+	m.running = maintenance.IsRunning()
+}
+
+func healthTick() tea.Cmd {
+	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
+		return healthTickMsg(t)
+	})
+}
+
+func runMaintenance() tea.Cmd {
+	return func() tea.Msg {
+		return healthDoneMsg(maintenance.Run())
+	}
+}
+
+func (m healthModel) Init() tea.Cmd {
+	return healthTick()
+}
+
+func (m healthModel) Update(msg tea.Msg) (healthModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case healthTickMsg:
+		m.refresh()
+		return m, healthTick()
+
+	case healthDoneMsg:
+		m.refresh()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			if !m.running {
+				m.running = true
+				return m, runMaintenance()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m healthModel) View() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true)
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#6AB547")).Bold(true)
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Bold(true)
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#5000ff")).
+		Padding(0, 1)
+
+	// Status summary
+	status := labelStyle.Render("No maintenance run yet")
+	if m.hasRun {
+		if m.last.Healthy {
+			status = okStyle.Render("✔ Healthy")
+		} else {
+			status = errStyle.Render("✖ Integrity check failed")
+		}
+	}
+	if m.running {
+		status += "  " + valueStyle.Render("(maintenance running...)")
+	}
+
+	summary := titleStyle.Render("Repository Health") + "\n\n" + status + "\n\n"
+	if m.hasRun {
+		summary += labelStyle.Render("Last run:  ") + valueStyle.Render(m.last.Time.Format("2006-01-02 15:04:05")) + "\n" +
+			labelStyle.Render("Repo size: ") + valueStyle.Render(maintenance.FormatSize(m.last.SizeBytes)) + "\n"
+	}
+	if interval, enabled := maintenance.Interval(); enabled {
+		summary += labelStyle.Render("Schedule:  ") + valueStyle.Render("every "+interval.String())
+	} else {
+		summary += labelStyle.Render("Schedule:  ") + valueStyle.Render("disabled")
+	}
+
+	// Steps of the last run
+	steps := titleStyle.Render("Last Run") + "\n\n"
+	if !m.hasRun {
+		steps += labelStyle.Render("Press [r] to run maintenance now")
+	}
+	for _, s := range m.last.Steps {
+		mark := okStyle.Render("✔")
+		if !s.OK {
+			mark = errStyle.Render("✖")
+		}
+		steps += fmt.Sprintf("%s %-14s %s\n", mark, s.Name, labelStyle.Render(s.Duration.Round(time.Millisecond).String()))
+	}
+
+	boxWidth := m.width/2 - 2
+	if boxWidth < 20 {
+		boxWidth = 20
+	}
+	top := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		boxStyle.Width(boxWidth).Render(summary),
+		boxStyle.Width(boxWidth).Render(strings.TrimRight(steps, "\n")),
+	)
+
+	// Size trend and failure output
+	trend := titleStyle.Render("Size Trend") + "\n\n" + m.sizeTrend(valueStyle, labelStyle)
+	if m.hasRun {
+		for _, s := range m.last.Steps {
+			if !s.OK && s.Output != "" {
+				trend += "\n\n" + errStyle.Render(s.Name+" output:") + "\n" + truncateLines(s.Output, 6)
+			}
+		}
+	}
+	bottom := boxStyle.Width(boxWidth*2 + 2).Render(trend)
+
+	help := helpStyle.Render("[r] Run maintenance now  [tab] Switch tab")
+
+	return lipgloss.JoinVertical(lipgloss.Left, top, bottom, help)
+}
+
+// sizeTrend renders the recorded repository sizes as a small bar chart
+func (m healthModel) sizeTrend(valueStyle, labelStyle lipgloss.Style) string {
+	if len(m.history) == 0 {
+		return labelStyle.Render("No data yet")
+	}
+
+	bars := []rune("▁▂▃▄▅▆▇█")
+	var min, max int64 = m.history[0].SizeBytes, m.history[0].SizeBytes
+	for _, r := range m.history {
+		if r.SizeBytes < min {
+			min = r.SizeBytes
+		}
+		if r.SizeBytes > max {
+			max = r.SizeBytes
+		}
+	}
+
+	var chart strings.Builder
+	for _, r := range m.history {
+		idx := 0
+		if max > min {
+			idx = int((r.SizeBytes - min) * int64(len(bars)-1) / (max - min))
+		}
+		chart.WriteRune(bars[idx])
+	}
+
+	first := m.history[0]
+	return valueStyle.Render(chart.String()) + "\n" +
+		labelStyle.Render(fmt.Sprintf("%s (%s) → %s (%s)",
+			maintenance.FormatSize(first.SizeBytes), first.Time.Format("Jan 02"),
+			maintenance.FormatSize(m.last.SizeBytes), m.last.Time.Format("Jan 02")))
+}
+
+// truncateLines keeps at most n lines of s
+func truncateLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = append(lines[:n], "...")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxHighlightBytes is the largest file that gets syntax highlighting,
+// bigger ones are shown plain to keep the TUI responsive
+const maxHighlightBytes = 256 * 1024
+
+// codeStyle is the chroma theme used for file contents
+var codeStyle = styles.Get("monokai")
+
+// span is a piece of highlighted code and the style it is drawn with
+type span struct {
+	text  string
+	style lipgloss.Style
+}
+
+// highlightLines splits a file into lines coloured for the language its
+// name suggests. Unknown languages and large files come back plain.
+func highlightLines(name, content string) []string {
+	spans := highlightSpans(name, content)
+	if spans == nil {
+		return strings.Split(content, "\n")
+	}
+
+	lines := make([]string, len(spans))
+	for i, line := range spans {
+		for _, s := range line {
+			lines[i] += s.style.Render(s.text)
+		}
+	}
+	return lines
+}
+
+// highlightSpans splits code into lines of styled tokens, or returns nil
+// when the language is unknown or the code too large to highlight
+func highlightSpans(name, content string) [][]span {
+	lexer := lexers.Match(name)
+	if lexer == nil || len(content) > maxHighlightBytes {
+		return nil
+	}
+
+	iter, err := chroma.Coalesce(lexer).Tokenise(nil, content)
+	if err != nil {
+		return nil
+	}
+
+	// Tokens are split at line breaks so no colour runs across lines
+	lines := [][]span{nil}
+	for _, token := range iter.Tokens() {
+		style := tokenStyle(token.Type)
+		for i, piece := range strings.Split(token.Value, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if piece != "" {
+				lines[len(lines)-1] = append(lines[len(lines)-1], span{text: piece, style: style})
+			}
+		}
+	}
+	return lines
+}
+
+// tokenStyle converts a chroma style entry into a lipgloss style
+func tokenStyle(t chroma.TokenType) lipgloss.Style {
+	entry := codeStyle.Get(t)
+	style := lipgloss.NewStyle()
+	if entry.Colour.IsSet() {
+		style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+	if entry.Bold == chroma.Yes {
+		style = style.Bold(true)
+	}
+	if entry.Italic == chroma.Yes {
+		style = style.Italic(true)
+	}
+	return style
+}
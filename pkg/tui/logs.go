@@ -1,10 +1,14 @@
 package tui
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nim-sam/gitport/pkg/logger"
@@ -12,24 +16,307 @@ import (
 
 type LogItem struct {
 	level, desc, time string
+	record            logger.Record
 }
 
 func (i LogItem) FilterValue() string { return i.desc }
 
+// timeRanges are the presets cycled with 't', zero meaning no limit
+var timeRanges = []struct {
+	label string
+	since time.Duration
+}{
+	{"All time", 0},
+	{"Last 15m", 15 * time.Minute},
+	{"Last hour", time.Hour},
+	{"Last 24h", 24 * time.Hour},
+	{"Last 7 days", 7 * 24 * time.Hour},
+}
+
+// logLevels are toggled with the number keys, in order
+var logLevels = []string{"INFO", "WARN", "ERROR"}
+
 type logModel struct {
 	list  list.Model
 	ready bool
+
+	// all holds every loaded entry, newest first; the list shows those matching the filters
+	all []list.Item
+
+	// logsStart is where the loaded part of logs.csv begins, zero once it's
+	// all loaded; archives are the rotated logs not loaded yet, newest first
+	logsStart int64
+	archives  []string
+	loading   bool
+	status    string
+
+	// records streams new log entries; while paused they wait in pending, newest first
+	records <-chan logger.Record
+	paused  bool
+	pending []list.Item
+
+	// Query bar and filters
+	input     textinput.Model
+	editing   bool
+	queryText string
+	query     logger.Query
+	timeRange int
+	hidden    map[string]bool
+	saved     []string
+}
+
+// newLogModel reads the newest logs and follows new entries from records
+func newLogModel(width, height int, records <-chan logger.Record) logModel {
+	items, start := fetchLogItems()
+
+	l := list.New(items, logDelegate{}, width, max(height-2, 1)) // Leave room for the query bar and footer
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowPagination(false)
+	l.SetFilteringEnabled(false)    // The query bar replaces the fuzzy finder
+	l.KeyMap.Quit.SetEnabled(false) // Don't let 'q' kill the whole app
+
+	input := textinput.New()
+	input.Prompt = "Query: "
+	input.Placeholder = `level:ERROR user:alice repo:foo since:2h "push"`
+	input.CharLimit = 200
+
+	archives, _ := logger.LogArchives()
+
+	return logModel{
+		list:      l,
+		ready:     true,
+		all:       items,
+		logsStart: start,
+		archives:  archives,
+		records:   records,
+		input:     input,
+		hidden:    make(map[string]bool),
+		saved:     logger.SavedQueries(),
+	}
+}
+
+// logPageSize is how many records of logs.csv are read at a time
+const logPageSize = 1000
+
+// olderLogsMsg carries a page of logs.csv, or the records of a rotated logs
+// archive when name is set
+type olderLogsMsg struct {
+	name  string
+	start int64
+	items []list.Item
+	err   error
+}
+
+// loadOlderLogs reads the page of logs.csv before end in the background
+func loadOlderLogs(end int64) tea.Cmd {
+	return func() tea.Msg {
+		records, start, err := logger.ReadLogs(end, logPageSize)
+		return olderLogsMsg{start: start, items: logItems(records), err: err}
+	}
+}
+
+// loadLogArchive reads a rotated logs archive in the background
+func loadLogArchive(name string) tea.Cmd {
+	return func() tea.Msg {
+		records, err := logger.ReadLogArchive(name)
+		return olderLogsMsg{name: name, items: logItems(records), err: err}
+	}
+}
+
+// newLogMsg carries a log entry written while the session is open
+type newLogMsg logger.Record
+
+// waitForLog delivers the next log entry from the subscription
+func waitForLog(records <-chan logger.Record) tea.Cmd {
+	if records == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		r, ok := <-records
+		if !ok {
+			return nil
+		}
+		return newLogMsg(r)
+	}
+}
+
+func (m logModel) Init() tea.Cmd {
+	return waitForLog(m.records)
+}
+
+// matches reports whether an entry passes the query, time range and level toggles
+func (m logModel) matches(item list.Item) bool {
+	i, ok := item.(LogItem)
+	if !ok {
+		return false
+	}
+	if m.hidden[i.record.Level] {
+		return false
+	}
+	if since := timeRanges[m.timeRange].since; since > 0 && i.record.Time.Before(time.Now().Add(-since)) {
+		return false
+	}
+	return m.query.Match(i.record)
+}
+
+// filtered returns the entries that match the current filters
+func (m logModel) filtered(items []list.Item) []list.Item {
+	var out []list.Item
+	for _, item := range items {
+		if m.matches(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// applyFilters rebuilds the list from every loaded entry
+func (m *logModel) applyFilters() tea.Cmd {
+	cmd := m.list.SetItems(m.filtered(m.all))
+	m.list.Select(0)
+	return cmd
+}
+
+// setQuery parses and applies a query, keeping the previous one on error
+func (m *logModel) setQuery(text string) (tea.Cmd, error) {
+	query, err := logger.ParseQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	m.queryText = strings.TrimSpace(text)
+	m.query = query
+	return m.applyFilters(), nil
+}
+
+// savedIndex returns the position of the current query among the saved ones, or -1
+func (m logModel) savedIndex() int {
+	for i, q := range m.saved {
+		if q == m.queryText {
+			return i
+		}
+	}
+	return -1
 }
 
 func (m logModel) Update(msg tea.Msg) (logModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		height := msg.Height - 1 // Leave room for footer
-		if height < 1 {
-			height = 1
-		}
-		m.list.SetSize(msg.Width, height)
+		m.list.SetSize(msg.Width, max(msg.Height-2, 1)) // Leave room for the query bar and footer
+		m.input.Width = max(msg.Width-len(m.input.Prompt)-1, 1)
 		return m, nil
+
+	case olderLogsMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "Could not read " + cmp.Or(msg.name, logger.Logs) + ": " + msg.err.Error()
+			return m, nil
+		}
+		if msg.name == "" {
+			m.logsStart = msg.start
+		}
+		m.status = ""
+		m.all = append(m.all, msg.items...)
+		return m, m.list.SetItems(append(m.list.Items(), m.filtered(msg.items)...))
+
+	case newLogMsg:
+		item := newLogItem(logger.Record(msg))
+		if m.paused {
+			m.pending = append([]list.Item{item}, m.pending...)
+			return m, waitForLog(m.records)
+		}
+		m.all = append([]list.Item{item}, m.all...)
+		if !m.matches(item) {
+			return m, waitForLog(m.records)
+		}
+		cmd := m.list.InsertItem(0, item)
+		m.list.Select(0)
+		return m, tea.Batch(cmd, waitForLog(m.records))
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.handleQueryKeys(msg)
+		}
+
+		switch msg.String() {
+		case "/":
+			m.editing = true
+			m.status = ""
+			m.input.SetValue(m.queryText)
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		case "esc":
+			if m.queryText != "" {
+				cmd, _ := m.setQuery("")
+				return m, cmd
+			}
+		case "t":
+			m.timeRange = (m.timeRange + 1) % len(timeRanges)
+			return m, m.applyFilters()
+		case "1", "2", "3":
+			level := logLevels[int(msg.String()[0]-'1')]
+			m.hidden[level] = !m.hidden[level]
+			return m, m.applyFilters()
+		case "s":
+			if m.queryText == "" || m.savedIndex() >= 0 {
+				return m, nil
+			}
+			m.saved = append(m.saved, m.queryText)
+			if err := logger.SaveQueries(m.saved); err != nil {
+				m.status = "Could not save query: " + err.Error()
+			} else {
+				m.status = "Query saved"
+			}
+			return m, nil
+		case "p":
+			if len(m.saved) == 0 {
+				return m, nil
+			}
+			next := m.saved[(m.savedIndex()+1)%len(m.saved)]
+			cmd, err := m.setQuery(next)
+			if err != nil {
+				m.status = "Invalid saved query: " + err.Error()
+			}
+			return m, cmd
+		case "x":
+			i := m.savedIndex()
+			if i < 0 {
+				return m, nil
+			}
+			m.saved = append(m.saved[:i], m.saved[i+1:]...)
+			if err := logger.SaveQueries(m.saved); err != nil {
+				m.status = "Could not delete query: " + err.Error()
+			} else {
+				m.status = "Query deleted"
+			}
+			return m, nil
+		case "o":
+			if m.loading {
+				break
+			}
+			if m.logsStart > 0 {
+				m.loading = true
+				m.status = "Loading older entries of " + logger.Logs + "..."
+				return m, loadOlderLogs(m.logsStart)
+			}
+			if len(m.archives) == 0 {
+				break
+			}
+			name := m.archives[0]
+			m.archives = m.archives[1:]
+			m.loading = true
+			m.status = "Loading " + name + "..."
+			return m, loadLogArchive(name)
+		case "f":
+			m.paused = !m.paused
+			if m.paused || len(m.pending) == 0 {
+				return m, nil
+			}
+			// Catch up on everything logged while paused
+			m.all = append(m.pending, m.all...)
+			m.pending = nil
+			return m, m.applyFilters()
+		}
 	}
 
 	var cmd tea.Cmd
@@ -37,13 +324,86 @@ func (m logModel) Update(msg tea.Msg) (logModel, tea.Cmd) {
 	return m, cmd
 }
 
+// handleQueryKeys edits the query bar
+func (m logModel) handleQueryKeys(msg tea.KeyMsg) (logModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.editing = false
+		m.status = ""
+		m.input.Blur()
+		return m, nil
+
+	case "enter":
+		cmd, err := m.setQuery(m.input.Value())
+		if err != nil {
+			m.status = "Invalid query: " + err.Error()
+			return m, nil
+		}
+		m.editing = false
+		m.status = ""
+		m.input.Blur()
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// filterBar shows the query being edited, or the active query and filters
+func (m logModel) filterBar() string {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	if m.editing {
+		return m.input.View()
+	}
+
+	query := dim.Render("[/] Query")
+	if m.queryText != "" {
+		query = lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true).Render("Query: ") + m.queryText
+		if i := m.savedIndex(); i >= 0 {
+			query += dim.Render(fmt.Sprintf("  (saved %d/%d)", i+1, len(m.saved)))
+		}
+	}
+
+	var levels []string
+	for i, level := range logLevels {
+		style := lipgloss.NewStyle()
+		if m.hidden[level] {
+			style = dim.Strikethrough(true)
+		}
+		levels = append(levels, style.Render(fmt.Sprintf("%d:%s", i+1, level)))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Left,
+		query, "   ",
+		dim.Render("[t] "), timeRanges[m.timeRange].label, "   ",
+		strings.Join(levels, " "))
+}
+
 func (m logModel) View() string {
 	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
 	pagination := helpStyle.Render(m.list.Paginator.View())
-	help := helpStyle.Render("[up/down] Navigate logs  [tab] Switch tab")
-	footer := lipgloss.JoinHorizontal(lipgloss.Left, pagination, "  ", help)
+	keys := "[up/down] Navigate  [f] Follow/pause  [s/p/x] Save/cycle/delete query  [tab] Switch tab"
+	if m.logsStart > 0 {
+		keys += "  [o] Load older logs"
+	} else if len(m.archives) > 0 {
+		keys += fmt.Sprintf("  [o] Load older logs (%d archived)", len(m.archives))
+	}
+	help := helpStyle.Render(keys)
+
+	follow := lipgloss.NewStyle().Foreground(lipgloss.Color("#00C853")).Render("● Following")
+	if m.paused {
+		follow = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")).Render(fmt.Sprintf("❚❚ Paused (%d new)", len(m.pending)))
+	}
 
-	return lipgloss.JoinVertical(lipgloss.Left, m.list.View(), footer)
+	footer := lipgloss.JoinHorizontal(lipgloss.Left, follow, "  ", pagination, "  ", help)
+	if m.status != "" {
+		footer = lipgloss.JoinHorizontal(lipgloss.Left, follow, "  ", helpStyle.Render(m.status))
+	}
+	footer = lipgloss.NewStyle().MaxWidth(m.list.Width()).Render(footer)
+	bar := lipgloss.NewStyle().MaxWidth(m.list.Width()).Render(m.filterBar())
+
+	return lipgloss.JoinVertical(lipgloss.Left, bar, m.list.View(), footer)
 }
 
 type logDelegate struct{}
@@ -85,39 +445,38 @@ func (d logDelegate) Render(w io.Writer, m list.Model, index int, listItem list.
 	}
 }
 
-func fetchLogItems() []list.Item {
-	// Call the function we created in the logger package
-	records, err := logger.ReadLogs()
-	if err != nil {
-		// Return a single error item if the file can't be read
-		return []list.Item{LogItem{level: "ERROR", desc: "Could not read logs: " + err.Error(), time: ""}}
+// newLogItem shows a record's fields after its message
+func newLogItem(r logger.Record) LogItem {
+	desc := r.Message
+	if fields := r.FieldString(); fields != "" {
+		desc += "  " + fields
 	}
 
-	var items []list.Item
-	// Skip the first row if it's the header "Date,Time,Level,Message"
-	startIdx := 0
-	if len(records) > 0 && records[0][0] == "Date" {
-		startIdx = 1
+	return LogItem{
+		time:   r.Time.Local().Format("2006-01-02 15:04:05"),
+		level:  r.Level,
+		desc:   desc,
+		record: r,
 	}
+}
 
-	for i := startIdx; i < len(records); i++ {
-		row := records[i]
-		// Ensure the row has enough columns to prevent index out of range
-		if len(row) < 4 {
-			continue
-		}
-
-		items = append(items, LogItem{
-			time:  fmt.Sprintf("%s %s", row[0], row[1]), // Combines Date and Time
-			level: row[2],
-			desc:  row[3],
-		})
+// logItems converts records to list items, newest first
+func logItems(records []logger.Record) []list.Item {
+	items := make([]list.Item, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		items = append(items, newLogItem(records[i]))
 	}
+	return items
+}
 
-	// Optional: Reverse items if you want the newest logs at the top
-	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
-		items[i], items[j] = items[j], items[i]
+// fetchLogItems reads the newest page of logs.csv and returns where it starts.
+// Older pages and archives are loaded on demand.
+func fetchLogItems() ([]list.Item, int64) {
+	records, start, err := logger.ReadLogs(-1, logPageSize)
+	if err != nil {
+		// Return a single error item if the file can't be read
+		return []list.Item{newLogItem(logger.Record{Time: time.Now(), Level: "ERROR", Message: "Could not read logs: " + err.Error()})}, 0
 	}
 
-	return items
+	return logItems(records), start
 }
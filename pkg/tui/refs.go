@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	branchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#6AB547")).Bold(true) // Green
+	tagStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")).Bold(true) // Orange
+)
+
+// refOption is an entry of the Commit History ref picker
+type refOption struct {
+	name plumbing.ReferenceName
+	// all merges the history of every branch and tag
+	all bool
+}
+
+func (r refOption) FilterValue() string { return r.Label() }
+
+// Label is the short name shown in the picker and help bar
+func (r refOption) Label() string {
+	if r.all {
+		return "All refs"
+	}
+	return r.name.Short()
+}
+
+// listRefs returns the ref picker entries: all refs, then branches, then tags
+func listRefs(repo *git.Repository) []refOption {
+	var branches, tags []refOption
+	iter, err := repo.References()
+	if err == nil {
+		iter.ForEach(func(ref *plumbing.Reference) error {
+			switch {
+			case ref.Name().IsBranch():
+				branches = append(branches, refOption{name: ref.Name()})
+			case ref.Name().IsTag():
+				tags = append(tags, refOption{name: ref.Name()})
+			}
+			return nil
+		})
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].name < branches[j].name })
+	sort.Slice(tags, func(i, j int) bool { return tags[i].name < tags[j].name })
+
+	return append(append([]refOption{{all: true}}, branches...), tags...)
+}
+
+// defaultRef is the branch HEAD points at
+func defaultRef(repo *git.Repository) refOption {
+	if head, err := repo.Reference(plumbing.HEAD, false); err == nil && head.Type() == plumbing.SymbolicReference {
+		return refOption{name: head.Target()}
+	}
+	return refOption{name: plumbing.HEAD}
+}
+
+// peelCommit resolves a ref, following annotated tags, to the commit it names
+func peelCommit(repo *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	if tag, err := repo.TagObject(hash); err == nil {
+		return tag.Commit()
+	}
+	return repo.CommitObject(hash)
+}
+
+// refTips returns the commits a ref option starts its history from
+func refTips(repo *git.Repository, opt refOption) []*object.Commit {
+	var tips []*object.Commit
+	if !opt.all {
+		ref, err := repo.Reference(opt.name, true)
+		if err != nil {
+			return nil
+		}
+		if c, err := peelCommit(repo, ref.Hash()); err == nil {
+			tips = append(tips, c)
+		}
+		return tips
+	}
+
+	iter, err := repo.References()
+	if err != nil {
+		return nil
+	}
+	iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsBranch() || ref.Name().IsTag() {
+			if c, err := peelCommit(repo, ref.Hash()); err == nil {
+				tips = append(tips, c)
+			}
+		}
+		return nil
+	})
+	return tips
+}
+
+// tagPrefix marks tag decorations, as git log --decorate does
+const tagPrefix = "tag: "
+
+// refDecorations maps commits to the branch and tag labels pointing at them
+func refDecorations(repo *git.Repository) map[plumbing.Hash][]string {
+	decorations := make(map[plumbing.Hash][]string)
+	iter, err := repo.References()
+	if err != nil {
+		return decorations
+	}
+	iter.ForEach(func(ref *plumbing.Reference) error {
+		var label string
+		switch {
+		case ref.Name().IsBranch():
+			label = ref.Name().Short()
+		case ref.Name().IsTag():
+			label = tagPrefix + ref.Name().Short()
+		default:
+			return nil
+		}
+		if c, err := peelCommit(repo, ref.Hash()); err == nil {
+			decorations[c.Hash] = append(decorations[c.Hash], label)
+		}
+		return nil
+	})
+	for hash := range decorations {
+		sort.Strings(decorations[hash])
+	}
+	return decorations
+}
+
+// renderDecorations colours branch and tag labels for the commit list
+func renderDecorations(labels []string) string {
+	styled := make([]string, len(labels))
+	for i, label := range labels {
+		if strings.HasPrefix(label, tagPrefix) {
+			styled[i] = tagStyle.Render(label)
+		} else {
+			styled[i] = branchStyle.Render(label)
+		}
+	}
+	return "(" + strings.Join(styled, ", ") + ") "
+}
+
+// commitWalker walks the history of several tips at once, newest commit
+// first, so merged histories stay in chronological order
+type commitWalker struct {
+	frontier commitHeap
+	seen     map[plumbing.Hash]bool
+	pushed   int
+}
+
+func newCommitWalker(tips []*object.Commit) *commitWalker {
+	w := &commitWalker{seen: make(map[plumbing.Hash]bool)}
+	for _, c := range tips {
+		w.push(c)
+	}
+	return w
+}
+
+func (w *commitWalker) push(c *object.Commit) {
+	if w.seen[c.Hash] {
+		return
+	}
+	w.seen[c.Hash] = true
+	heap.Push(&w.frontier, walkedCommit{commit: c, order: w.pushed})
+	w.pushed++
+}
+
+// done reports whether the walk has reached the end of the history
+func (w *commitWalker) done() bool {
+	return len(w.frontier) == 0
+}
+
+// next returns up to n more commits, fewer once the history runs out
+func (w *commitWalker) next(n int) []*object.Commit {
+	var commits []*object.Commit
+	for len(commits) < n && len(w.frontier) > 0 {
+		c := heap.Pop(&w.frontier).(walkedCommit).commit
+		commits = append(commits, c)
+
+		c.Parents().ForEach(func(p *object.Commit) error {
+			w.push(p)
+			return nil
+		})
+	}
+	return commits
+}
+
+// walkedCommit is a commit waiting in a walker's frontier. order is when it
+// was reached, so commits made at the same time come out in that order.
+type walkedCommit struct {
+	commit *object.Commit
+	order  int
+}
+
+// commitHeap is a heap of commits, newest commit time first
+type commitHeap []walkedCommit
+
+func (h commitHeap) Len() int { return len(h) }
+
+func (h commitHeap) Less(i, j int) bool {
+	ti, tj := h[i].commit.Committer.When, h[j].commit.Committer.When
+	if ti.Equal(tj) {
+		return h[i].order < h[j].order
+	}
+	return ti.After(tj)
+}
+
+func (h commitHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *commitHeap) Push(x any) { *h = append(*h, x.(walkedCommit)) }
+
+func (h *commitHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// newRefPicker builds the list shown when choosing which ref to browse
+func newRefPicker(refs []refOption, width, height int) list.Model {
+	items := make([]list.Item, len(refs))
+	for i, r := range refs {
+		items[i] = r
+	}
+	l := list.New(items, refDelegate{}, width, height)
+	l.Title = "Browse history of"
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.KeyMap.Quit.SetEnabled(false)
+	return l
+}
+
+type refDelegate struct{}
+
+func (d refDelegate) Height() int                               { return 1 }
+func (d refDelegate) Spacing() int                              { return 0 }
+func (d refDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d refDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	r, ok := listItem.(refOption)
+	if !ok {
+		return
+	}
+
+	label := r.Label()
+	switch {
+	case r.all:
+		label = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true).Render(label)
+	case r.name.IsTag():
+		label = tagStyle.Render(label)
+	default:
+		label = branchStyle.Render(label)
+	}
+
+	cursor := "  "
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true).Render("> ")
+	}
+	fmt.Fprint(w, cursor+label)
+}
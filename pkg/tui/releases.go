@@ -0,0 +1,430 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nim-sam/gitport/pkg/releases"
+)
+
+// tagCommitMsg asks the Releases tab to tag a commit
+type tagCommitMsg struct {
+	hash string
+}
+
+type releaseItem releases.Release
+
+func (i releaseItem) FilterValue() string { return i.Tag }
+
+// Release form fields, in the order they are visited
+const (
+	fieldTag = iota
+	fieldTarget
+	fieldMessage
+	fieldNotes
+)
+
+type releasesModel struct {
+	list     list.Model
+	viewport viewport.Model
+	focus    bool   // false = List focused, true = Viewport focused
+	shown    string // Tag whose details are in the viewport
+	err      string
+	status   string
+	by       string // Admin creating releases, recorded as the tagger; empty when read-only
+
+	// Form for creating a release
+	creating bool
+	field    int
+	inputs   []textinput.Model // Tag, target and message
+	notes    textarea.Model
+	formErr  string
+
+	width  int
+	height int
+}
+
+// newReleases lists the tags of the repository. by is the admin using the
+// tab, or empty for users who may only browse releases.
+func newReleases(by string) releasesModel {
+	l := list.New(nil, releaseDelegate{}, 0, 0)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.KeyMap.Quit.SetEnabled(false)
+
+	tag := textinput.New()
+	tag.Placeholder = "v1.2.0"
+	tag.CharLimit = 100
+
+	target := textinput.New()
+	target.Placeholder = "branch, tag or commit hash"
+	target.CharLimit = 100
+
+	message := textinput.New()
+	message.Placeholder = "Release title, defaults to the tag name"
+	message.CharLimit = 200
+
+	notes := textarea.New()
+	notes.Placeholder = "Release notes (Markdown)"
+	notes.ShowLineNumbers = false
+	notes.SetHeight(8)
+
+	m := releasesModel{
+		list:     l,
+		viewport: viewport.New(0, 0),
+		by:       by,
+		inputs:   []textinput.Model{tag, target, message},
+		notes:    notes,
+	}
+	m.reload()
+	return m
+}
+
+// reload lists the tags again, keeping the selection on the same tag
+func (m *releasesModel) reload() {
+	tags, err := releases.List()
+	if err != nil {
+		m.err = "Could not list tags: " + err.Error()
+		return
+	}
+	m.err = ""
+
+	items := make([]list.Item, len(tags))
+	selected := 0
+	for i, r := range tags {
+		items[i] = releaseItem(r)
+		if r.Tag == m.shown {
+			selected = i
+		}
+	}
+	m.list.SetItems(items)
+	m.list.Select(selected)
+
+	// Tags pushed since may have changed the changelog
+	m.shown = ""
+	m.showSelected()
+}
+
+// emptyText is shown in place of the details when there are no tags
+func (m releasesModel) emptyText() string {
+	if m.by == "" {
+		return "No tags yet."
+	}
+	return "No tags yet. Press [n] to create a release."
+}
+
+// showSelected fills the viewport with the selected release and its changelog
+func (m *releasesModel) showSelected() {
+	item, ok := m.list.SelectedItem().(releaseItem)
+	if !ok {
+		m.shown = ""
+		m.viewport.SetContent(lipgloss.NewStyle().Foreground(lipgloss.Color("#505050")).Render(m.emptyText()))
+		return
+	}
+	if item.Tag == m.shown {
+		return
+	}
+	m.shown = item.Tag
+
+	// Releases are listed newest first, so the previous one comes next
+	previous := ""
+	if next := m.list.Index() + 1; next < len(m.list.Items()) {
+		previous = m.list.Items()[next].(releaseItem).Tag
+	}
+	m.viewport.SetContent(renderRelease(releases.Release(item), previous, m.viewport.Width))
+	m.viewport.GotoTop()
+}
+
+// renderRelease describes a release, its notes and the commits it added
+func renderRelease(r releases.Release, previous string, width int) string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+	textStyle := lipgloss.NewStyle().Width(max(width-2, 10))
+
+	var b strings.Builder
+	b.WriteString(tagStyle.Render(r.Tag) + "  " + labelStyle.Render(shortHash(r.Commit)) + "\n")
+	if r.Annotated {
+		b.WriteString(labelStyle.Render(fmt.Sprintf("Tagged by %s on %s", r.Tagger, r.Date.Local().Format("Jan 02, 2006 15:04"))) + "\n")
+		if r.Message != "" && r.Message != r.Tag {
+			b.WriteString("\n" + textStyle.Render(r.Message) + "\n")
+		}
+	} else {
+		b.WriteString(labelStyle.Render("Lightweight tag of a commit from "+r.Date.Local().Format("Jan 02, 2006")) + "\n")
+	}
+
+	if notes := releases.ReadNotes(r.Tag); notes != "" {
+		b.WriteString("\n" + titleStyle.Render("Release Notes") + "\n" + textStyle.Render(strings.TrimSpace(notes)) + "\n")
+	}
+
+	heading := "Commits"
+	if previous != "" {
+		heading = "Changes since " + previous
+	}
+	b.WriteString("\n" + titleStyle.Render(heading) + "\n")
+	changes, err := releases.Changelog(r.Tag, previous)
+	switch {
+	case err != nil:
+		b.WriteString(dim.Render("Could not build the changelog: " + err.Error()))
+	case len(changes) == 0:
+		b.WriteString(dim.Render("No new commits"))
+	}
+	for _, change := range changes {
+		hash, subject, _ := strings.Cut(change, " ")
+		b.WriteString(dim.Render(hash) + " " + subject + "\n")
+	}
+	return b.String()
+}
+
+// shortHash abbreviates a commit hash
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// openForm starts creating a release on a target commit or ref
+func (m *releasesModel) openForm(target string) tea.Cmd {
+	m.creating = true
+	m.formErr = ""
+	m.status = ""
+	for i := range m.inputs {
+		m.inputs[i].SetValue("")
+	}
+	m.inputs[fieldTarget].SetValue(target)
+	m.notes.SetValue("")
+	return m.focusField(fieldTag)
+}
+
+// focusField moves the form cursor to a field
+func (m *releasesModel) focusField(field int) tea.Cmd {
+	m.field = field
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+	}
+	m.notes.Blur()
+	if field == fieldNotes {
+		return m.notes.Focus()
+	}
+	return m.inputs[field].Focus()
+}
+
+func (m releasesModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m releasesModel) Update(msg tea.Msg) (releasesModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		listWidth := max(msg.Width/3, 20)
+		targetHeight := max(msg.Height-3, 1) // Help + viewport border
+		m.list.SetSize(listWidth, targetHeight)
+		m.viewport.Width = max(msg.Width-listWidth-4, 10)
+		m.viewport.Height = targetHeight
+		for i := range m.inputs {
+			m.inputs[i].Width = 50
+		}
+		m.notes.SetWidth(54)
+
+		// Re-render the details at the new width
+		m.shown = ""
+		m.showSelected()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.creating {
+			return m.updateForm(msg)
+		}
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		switch msg.String() {
+		case "n":
+			if m.by == "" {
+				break
+			}
+			// HEAD is the default branch of the bare repository
+			return m, m.openForm("HEAD")
+		case "enter":
+			m.focus = !m.focus
+			return m, nil
+		case "esc":
+			if m.focus {
+				m.focus = false
+				return m, nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	if m.focus {
+		m.viewport, cmd = m.viewport.Update(msg)
+		return m, cmd
+	}
+	m.list, cmd = m.list.Update(msg)
+	m.showSelected()
+	return m, cmd
+}
+
+// updateForm edits the release form, creating the tag on ctrl+s
+func (m releasesModel) updateForm(msg tea.KeyMsg) (releasesModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.creating = false
+		return m, nil
+
+	case "ctrl+s":
+		tag := strings.TrimSpace(m.inputs[fieldTag].Value())
+		target := strings.TrimSpace(m.inputs[fieldTarget].Value())
+		if tag == "" || target == "" {
+			m.formErr = "A tag name and a target commit are required"
+			return m, nil
+		}
+		err := releases.Create(tag, target, strings.TrimSpace(m.inputs[fieldMessage].Value()), m.notes.Value(), m.by)
+		if err != nil {
+			m.formErr = err.Error()
+			return m, nil
+		}
+		m.creating = false
+		m.status = "Created release " + tag
+		m.shown = tag
+		m.reload()
+		return m, nil
+
+	case "enter":
+		if m.field != fieldNotes {
+			return m, m.focusField(m.field + 1)
+		}
+
+	case "down", "ctrl+j":
+		if m.field != fieldNotes {
+			return m, m.focusField(m.field + 1)
+		}
+
+	case "up", "ctrl+k":
+		if m.field != fieldNotes {
+			return m, m.focusField(max(m.field-1, 0))
+		}
+
+	case "shift+tab":
+		return m, m.focusField((m.field + len(m.inputs)) % (len(m.inputs) + 1))
+	}
+
+	var cmd tea.Cmd
+	if m.field == fieldNotes {
+		m.notes, cmd = m.notes.Update(msg)
+	} else {
+		m.inputs[m.field], cmd = m.inputs[m.field].Update(msg)
+	}
+	return m, cmd
+}
+
+func (m releasesModel) View() string {
+	if m.creating {
+		return m.renderForm()
+	}
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	borderColor := lipgloss.Color("238")
+	if m.focus {
+		borderColor = lipgloss.Color("#5000ff")
+	}
+	vpStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1)
+
+	left := m.list.View()
+	if m.err != "" {
+		left = lipgloss.NewStyle().Width(m.list.Width()).Foreground(lipgloss.Color("#FF1B1C")).Render(m.err)
+	}
+	content := lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.list.Width()).Render(left), vpStyle.Render(m.viewport.View()))
+
+	keys := "[up/down] Select  [enter] Toggle details focus  [n] New release  [/] Filter  [tab] Switch tab"
+	if m.by == "" {
+		keys = "[up/down] Select  [enter] Toggle details focus  [/] Filter  [tab] Switch tab"
+	}
+	help := helpStyle.Render(keys)
+	if m.status != "" {
+		help = lipgloss.NewStyle().Foreground(lipgloss.Color("#6AB547")).Render(m.status) + "  " + help
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, content, help)
+}
+
+func (m releasesModel) renderForm() string {
+	formStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#5000ff")).
+		Padding(1, 2).
+		Width(60)
+
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	form := titleStyle.Render("New Release") + "\n\n" +
+		labelStyle.Render("Tag:") + "\n" + m.inputs[fieldTag].View() + "\n\n" +
+		labelStyle.Render("Commit:") + "\n" + m.inputs[fieldTarget].View() + "\n\n" +
+		labelStyle.Render("Title:") + "\n" + m.inputs[fieldMessage].View() + "\n\n" +
+		labelStyle.Render("Release Notes:") + "\n" + m.notes.View() + "\n\n"
+	if m.formErr != "" {
+		form += lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Render(m.formErr) + "\n\n"
+	}
+	form += helpStyle.Render("[↑/↓] Navigate  [shift+tab] Previous field  [ctrl+s] Create  [esc] Cancel")
+
+	formBox := formStyle.Render(form)
+
+	// Center the form
+	if m.width > 0 && m.height > 0 {
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			formBox,
+		)
+	}
+	return formBox
+}
+
+type releaseDelegate struct{}
+
+func (d releaseDelegate) Height() int                               { return 2 }
+func (d releaseDelegate) Spacing() int                              { return 1 }
+func (d releaseDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d releaseDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	r, ok := listItem.(releaseItem)
+	if !ok {
+		return
+	}
+
+	name := tagStyle.Render(r.Tag)
+	if index == m.Index() {
+		name = lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true).Render(r.Tag)
+	}
+	date := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050")).Render(r.Date.Local().Format("Jan 02, 2006"))
+	title := r.Message
+	if !r.Annotated || title == r.Tag {
+		title = ""
+	}
+	title, _, _ = strings.Cut(title, "\n")
+
+	fmt.Fprint(w, lipgloss.NewStyle().PaddingLeft(2).Render(name+"  "+date+"\n"+
+		lipgloss.NewStyle().Foreground(lipgloss.Color("#707070")).Render(truncate(title, max(m.Width()-4, 10)))))
+}
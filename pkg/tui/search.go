@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/nim-sam/gitport/pkg/logger"
+)
+
+// maxSearchResults bounds how many matching commits a search keeps
+const maxSearchResults = 500
+
+// commitQuery selects commits. The zero commitQuery matches everything.
+// Its terms are lower case, except an exact path.
+type commitQuery struct {
+	author string
+	path   string
+	// exactPath matches the file at path, or the files under it when it is
+	// a directory, rather than any path containing it
+	exactPath bool
+	// after and before bound the author time, inclusively like the Logs
+	// tab's since: and until:
+	after, before time.Time
+	// text must all appear in the message, or be a prefix of the hash
+	text []string
+}
+
+// parseCommitQuery reads a search such as `author:alice path:pkg/tui
+// after:2024-01-01 "fix crash" 3f2a`, with the Logs query bar syntax.
+// since: and until: work as after: and before:.
+func parseCommitQuery(input string) (commitQuery, error) {
+	var q commitQuery
+	parsed, err := logger.ParseQuery(input)
+	if err != nil {
+		return q, err
+	}
+	if len(parsed.Levels) > 0 {
+		return q, fmt.Errorf("unknown search term level:")
+	}
+
+	q.after, q.before = parsed.Since, parsed.Until
+	for _, text := range parsed.Text {
+		q.text = append(q.text, strings.ToLower(text))
+	}
+	for key, value := range parsed.Fields {
+		switch key {
+		case "author":
+			q.author = strings.ToLower(value)
+		case "path":
+			q.path = strings.ToLower(value)
+		default:
+			return q, fmt.Errorf("unknown search term %s:", key)
+		}
+	}
+	return q, nil
+}
+
+// match reports whether a commit satisfies every term of the query. The
+// touched files are compared last, as diffing trees is the slowest check.
+func (q commitQuery) match(c *object.Commit) bool {
+	if q.author != "" &&
+		!strings.Contains(strings.ToLower(c.Author.Name), q.author) &&
+		!strings.Contains(strings.ToLower(c.Author.Email), q.author) {
+		return false
+	}
+	if !q.after.IsZero() && c.Author.When.Before(q.after) {
+		return false
+	}
+	if !q.before.IsZero() && c.Author.When.After(q.before) {
+		return false
+	}
+
+	message := strings.ToLower(c.Message)
+	for _, text := range q.text {
+		if !strings.Contains(message, text) && !strings.HasPrefix(c.Hash.String(), text) {
+			return false
+		}
+	}
+
+	return q.path == "" || touchesPath(c, q.matchPath)
+}
+
+// matchPath reports whether a file path satisfies the path: term
+func (q commitQuery) matchPath(name string) bool {
+	if q.exactPath {
+		return name == q.path || strings.HasPrefix(name, q.path+"/")
+	}
+	return strings.Contains(strings.ToLower(name), q.path)
+}
+
+// touchesPath reports whether a commit changed a file whose path satisfies match
+func touchesPath(c *object.Commit, match func(name string) bool) bool {
+	tree, err := c.Tree()
+	if err != nil {
+		return false
+	}
+	var parentTree *object.Tree
+	if parent, err := c.Parent(0); err == nil {
+		if parentTree, err = parent.Tree(); err != nil {
+			return false
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return false
+	}
+	for _, change := range changes {
+		if (change.From.Name != "" && match(change.From.Name)) ||
+			(change.To.Name != "" && match(change.To.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// commitSearch is a search over the full history of the listed ref
+type commitSearch struct {
+	query     string
+	done      bool
+	truncated bool // More than maxSearchResults commits matched
+}
+
+// commitSearchMsg carries the results of a finished search
+type commitSearchMsg struct {
+	search    *commitSearch
+	items     []list.Item
+	truncated bool
+}
+
+// runCommitSearch walks the whole history of a source in the background,
+// with a repository of its own as the TUI keeps using its one
+func runCommitSearch(repoPath string, source *commitSource, search *commitSearch, q commitQuery) tea.Cmd {
+	return func() tea.Msg {
+		items := []list.Item{}
+		repo, err := git.PlainOpen(repoPath)
+		if err != nil {
+			return commitSearchMsg{search: search, items: items}
+		}
+
+		walker := newCommitWalker(refTips(repo, source.ref))
+		for {
+			page := walker.next(commitPageSize)
+			if len(page) == 0 {
+				break
+			}
+			for _, c := range page {
+				if !q.match(c) {
+					continue
+				}
+				if len(items) == maxSearchResults {
+					return commitSearchMsg{search: search, items: items, truncated: true}
+				}
+				items = append(items, source.item(c))
+			}
+		}
+		return commitSearchMsg{search: search, items: items}
+	}
+}
+
+// newSearchInput builds the Commit History search bar
+func newSearchInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "Search: "
+	input.Placeholder = `author:alice path:pkg/ after:2024-01-01 before:30d "message" or a hash prefix`
+	input.CharLimit = 200
+	return input
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestParseCommitQuery(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    commitQuery
+		wantErr bool
+	}{
+		{input: "", want: commitQuery{}},
+		{input: "Author:Alice Path:PKG/TUI", want: commitQuery{author: "alice", path: "pkg/tui"}},
+		{input: `"Fix crash" 3F2A`, want: commitQuery{text: []string{"fix crash", "3f2a"}}},
+		{
+			input: "after:2024-05-01 before:2024-05-02T10:00:00Z",
+			want: commitQuery{
+				after:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+				before: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			input: "since:2024-05-01 until:2024-05-02",
+			want: commitQuery{
+				after:  time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+				before: time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local),
+			},
+		},
+		{input: "level:error", wantErr: true},
+		{input: "branch:main", wantErr: true},
+		{input: "after:someday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseCommitQuery(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCommitQuery(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.after.Equal(tt.want.after) || !got.before.Equal(tt.want.before) {
+				t.Errorf("range = %v..%v, want %v..%v", got.after, got.before, tt.want.after, tt.want.before)
+			}
+			got.after, got.before = time.Time{}, time.Time{}
+			tt.want.after, tt.want.before = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCommitQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitQueryMatch(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := &object.Commit{
+		Hash:    plumbing.NewHash("3f2a9c0000000000000000000000000000000000"),
+		Author:  object.Signature{Name: "Alice Doe", Email: "alice@example.com", When: when},
+		Message: "Fix crash when the list is empty\n",
+	}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "", want: true},
+		{input: "author:alice", want: true},
+		{input: "author:EXAMPLE.COM", want: true},
+		{input: "author:bob", want: false},
+		{input: `"fix CRASH"`, want: true},
+		{input: "crash empty", want: true},
+		{input: "crash full", want: false},
+		{input: "3F2A", want: true},
+		{input: "2a9c", want: false},
+		{input: "after:2024-05-01T12:00:00Z", want: true},
+		{input: "after:2024-05-01T12:00:01Z", want: false},
+		{input: "before:2024-05-01T12:00:00Z", want: true},
+		{input: "before:2024-05-01T11:59:59Z", want: false},
+		{input: "until:2024-05-01T12:00:00Z", want: true},
+	}
+
+	for _, tt := range tests {
+		q, err := parseCommitQuery(tt.input)
+		if err != nil {
+			t.Fatalf("parseCommitQuery(%q): %v", tt.input, err)
+		}
+		if got := q.match(c); got != tt.want {
+			t.Errorf("%q matched = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCommitQueryPath(t *testing.T) {
+	dir := t.TempDir()
+	when := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	gitIn(t, dir, when, "init", "--quiet", "--initial-branch=main")
+	for _, name := range []string{"pkg/tui/Files.go", "pkg/tuition.txt"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitIn(t, dir, when, "add", name)
+		gitIn(t, dir, when, "commit", "--quiet", "-m", "add "+name)
+	}
+
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	walker := newCommitWalker(refTips(repo, refOption{name: plumbing.NewBranchReferenceName("main")}))
+	commits := walker.next(2) // tuition.txt, then Files.go
+
+	tests := []struct {
+		name  string
+		q     commitQuery
+		match []bool
+	}{
+		{name: "contains, ignoring case", q: commitQuery{path: "tui"}, match: []bool{true, true}},
+		{name: "file name", q: commitQuery{path: "files.go"}, match: []bool{false, true}},
+		{name: "exact directory", q: commitQuery{path: "pkg/tui", exactPath: true}, match: []bool{false, true}},
+		{name: "exact file", q: commitQuery{path: "pkg/tuition.txt", exactPath: true}, match: []bool{true, false}},
+		{name: "exact path keeps its case", q: commitQuery{path: "pkg/tui/files.go", exactPath: true}, match: []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, c := range commits {
+				if got := tt.q.match(c); got != tt.match[i] {
+					t.Errorf("%q matched = %v, want %v", c.Message, got, tt.match[i])
+				}
+			}
+		})
+	}
+}
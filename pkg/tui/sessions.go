@@ -0,0 +1,216 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nim-sam/gitport/pkg/maintenance"
+	"github.com/nim-sam/gitport/pkg/sessions"
+)
+
+// sessionsTickMsg refreshes the Sessions tab from the session registry
+type sessionsTickMsg time.Time
+
+type sessionsModel struct {
+	list     []sessions.Info
+	cursor   int
+	confirm  bool
+	admin    string
+	ownID    int64
+	status   string
+	statusOK bool
+	width    int
+	height   int
+}
+
+// newSessions lists the open sessions. admin and ownID identify the session
+// showing the tab, which can't terminate itself.
+func newSessions(admin string, ownID int64) sessionsModel {
+	return sessionsModel{list: sessions.List(), admin: admin, ownID: ownID}
+}
+
+func sessionsTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return sessionsTickMsg(t)
+	})
+}
+
+func (m sessionsModel) Init() tea.Cmd {
+	return sessionsTick()
+}
+
+// selected returns the session under the cursor
+func (m sessionsModel) selected() (sessions.Info, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.list) {
+		return sessions.Info{}, false
+	}
+	return m.list[m.cursor], true
+}
+
+// refresh reloads the registry, keeping the cursor on the same session
+func (m *sessionsModel) refresh() {
+	current, ok := m.selected()
+	m.list = sessions.List()
+	if ok {
+		for i, s := range m.list {
+			if s.ID == current.ID {
+				m.cursor = i
+				return
+			}
+		}
+	}
+	m.cursor = min(m.cursor, max(len(m.list)-1, 0))
+}
+
+func (m sessionsModel) Update(msg tea.Msg) (sessionsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case sessionsTickMsg:
+		m.refresh()
+		if _, ok := m.selected(); !ok {
+			m.confirm = false
+		}
+		return m, sessionsTick()
+
+	case tea.KeyMsg:
+		if m.confirm {
+			switch msg.String() {
+			case "y", "d":
+				m.confirm = false
+				s, ok := m.selected()
+				if !ok {
+					return m, nil
+				}
+				if err := sessions.Terminate(s.ID, m.admin); err != nil {
+					m.status, m.statusOK = "Could not terminate session: "+err.Error(), false
+				} else {
+					m.status, m.statusOK = fmt.Sprintf("Disconnected %s (%s)", s.User, s.Remote), true
+				}
+				m.refresh()
+			case "n", "esc":
+				m.confirm = false
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.list)-1 {
+				m.cursor++
+			}
+		case "d":
+			s, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+			if s.ID == m.ownID {
+				m.status, m.statusOK = "That's this session, press ctrl+c to leave instead", false
+				return m, nil
+			}
+			m.status = ""
+			m.confirm = true
+		}
+	}
+	return m, nil
+}
+
+func (m sessionsModel) View() string {
+	if m.confirm {
+		return m.renderConfirm()
+	}
+
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#5000ff")).Bold(true)
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Active Sessions (%d)", len(m.list))) + "\n\n")
+	b.WriteString(headerStyle.Render(fmt.Sprintf("  %-14s %-22s %-5s %-24s %-9s %s",
+		"USER", "ADDRESS", "TYPE", "COMMAND", "STARTED", "IN / OUT")) + "\n")
+
+	for i, s := range m.list {
+		command := s.Command
+		if command == "" {
+			command = "-"
+		}
+		user := s.User
+		if s.ID == m.ownID {
+			user += " (you)"
+		}
+		line := fmt.Sprintf("%-14s %-22s %-5s %-24s %-9s %s / %s",
+			truncate(user, 14), truncate(s.Remote, 22), s.Type, truncate(command, 24),
+			s.Start.Local().Format("15:04:05"),
+			maintenance.FormatSize(s.BytesIn), maintenance.FormatSize(s.BytesOut))
+
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	if m.status != "" {
+		color := "#FF1B1C"
+		if m.statusOK {
+			color = "#6AB547"
+		}
+		b.WriteString("\n" + lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(m.status) + "\n")
+	}
+
+	help := helpStyle.Render("[up/down] Select  [d] Disconnect  [tab] Switch tab")
+	return lipgloss.JoinVertical(lipgloss.Left, b.String(), help)
+}
+
+func (m sessionsModel) renderConfirm() string {
+	confirmStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#FF1B1C")).
+		Padding(1, 2).
+		Width(50)
+
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF1B1C")).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#707070"))
+	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#505050"))
+
+	s, _ := m.selected()
+	content := titleStyle.Render("Disconnect Session?") + "\n\n" +
+		labelStyle.Render("User:    ") + userStyle.Render(s.User) + "\n" +
+		labelStyle.Render("Address: ") + userStyle.Render(s.Remote) + "\n\n" +
+		helpStyle.Render("[y] Disconnect  [n] Cancel")
+
+	confirmBox := confirmStyle.Render(content)
+
+	// Center the confirm dialog
+	if m.width > 0 && m.height > 0 {
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			confirmBox,
+		)
+	}
+	return confirmBox
+}
+
+// truncate shortens s to n characters, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -7,20 +7,52 @@ import (
 
 type sessionState int
 
-//var tabs = []string{"Commits", "Branches", "Settings"}
+// Tab indices, in the order they appear in the header
+const (
+	tabDashboard = iota
+	tabActivity
+	tabCommits
+	tabFiles
+	tabBranches
+	tabReleases
+	tabLogs
+	tabSessions
+	tabHealth
+	tabAccount
+)
+
+var tabNames = []string{"Dashboard", "Activity", "Commit History", "Files", "Branches", "Releases", "Logs", "Sessions", "Health", "Account"}
+
+// adminTabs are the tabs of admins, who manage the server
+var adminTabs = []int{tabDashboard, tabActivity, tabCommits, tabFiles, tabBranches, tabReleases, tabLogs, tabSessions, tabHealth}
+
+// userTabs are the tabs of other users, who can only browse the repository
+var userTabs = []int{tabCommits, tabFiles, tabBranches, tabReleases, tabAccount}
 
 type mainModel struct {
 	state     sessionState
 	activeTab int
+	tabs      []int // Tabs the user may open, in order
+	admin     bool
 	dashboard dashboardModel
+	activity  activityModel
 	commitLog commitModel // Your existing model
+	files     filesModel
+	branches  branchesModel
+	releases  releasesModel
 	logFinder logModel
+	sessions  sessionsModel
+	health    healthModel
+	account   accountModel
 	width     int
 	height    int
 }
 
 func (m mainModel) Init() tea.Cmd {
-	return nil
+	if !m.admin {
+		return m.commitLog.Init()
+	}
+	return tea.Batch(m.commitLog.Init(), m.health.Init(), m.logFinder.Init(), m.activity.Init(), m.sessions.Init())
 }
 
 func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -33,34 +65,87 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		// Propagate size to ALL models so they can calculate layout
+		// Propagate size to the models of every tab so they can calculate layout
 		contentHeight := m.height - 2 // Header + blank line are outside the tab content
 		if contentHeight < 1 {
 			contentHeight = 1
 		}
 
-		// Update each model with appropriate size
-		var cmdD, cmdC, cmdL tea.Cmd
+		sizeMsg := tea.WindowSizeMsg{Width: m.width, Height: contentHeight}
+		for _, tab := range m.tabs {
+			cmds = append(cmds, m.updateTab(tab, sizeMsg))
+		}
+		return m, tea.Batch(cmds...)
+
+	case healthTickMsg, healthDoneMsg:
+		// Background maintenance updates reach the Health tab even when hidden
+		m.health, cmd = m.health.Update(msg)
+		return m, cmd
 
-		// Dashboard
-		dashMsg := tea.WindowSizeMsg{Width: m.width, Height: contentHeight}
-		m.dashboard, cmdD = m.dashboard.Update(dashMsg)
+	case commitPageMsg, commitCountMsg, commitSearchMsg, commitListMsg:
+		// Pages and searches keep loading while another tab is open
+		newModel, cmd := m.commitLog.Update(msg)
+		m.commitLog = newModel.(commitModel)
+		return m, cmd
 
-		// Commit History
-		commitMsg := tea.WindowSizeMsg{Width: m.width, Height: contentHeight}
-		newCommit, cmdC := m.commitLog.Update(commitMsg)
-		m.commitLog = newCommit.(commitModel)
+	case fileInfoMsg, blameMsg:
+		m.files, cmd = m.files.Update(msg)
+		return m, cmd
 
-		// Logs
-		logMsg := tea.WindowSizeMsg{Width: m.width, Height: contentHeight}
-		m.logFinder, cmdL = m.logFinder.Update(logMsg)
+	case blameRequestMsg:
+		m.activeTab = tabFiles
+		cmd = m.files.requestBlame(msg)
+		return m, cmd
 
-		return m, tea.Batch(cmdD, cmdC, cmdL)
+	case tagCommitMsg:
+		if !m.admin {
+			return m, nil
+		}
+		m.activeTab = tabReleases
+		cmd = m.releases.openForm(msg.hash)
+		return m, cmd
+
+	case showCommitMsg:
+		// Jump from a blamed line to the commit that last changed it
+		m.activeTab = tabCommits
+		m.commitLog.showCommit(msg.hash)
+		return m, nil
+
+	case branchHistoryMsg:
+		m.activeTab = tabCommits
+		cmd = m.commitLog.showRef(msg.ref)
+		return m, cmd
+
+	case fileHistoryMsg:
+		// Jump from a file to the commits that touched it
+		m.activeTab = tabCommits
+		cmd = m.commitLog.showPathHistory(msg.ref, msg.path)
+		return m, cmd
+
+	case sessionsTickMsg:
+		m.sessions, cmd = m.sessions.Update(msg)
+		return m, cmd
+
+	case activityEventMsg:
+		m.activity, cmd = m.activity.Update(msg)
+		return m, cmd
+
+	case olderLogsMsg, newLogMsg:
+		// New entries keep streaming in while another tab is open
+		m.logFinder, cmd = m.logFinder.Update(msg)
+		return m, cmd
 
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab":
-			m.activeTab = (m.activeTab + 1) % 3
+			m.activeTab = m.nextTab()
+			switch m.activeTab {
+			case tabReleases:
+				// Pick up tags pushed while the tab was hidden
+				m.releases.reload()
+			case tabBranches:
+				m.branches.reload()
+			}
 			return m, nil
 		case "ctrl+c":
 			return m, tea.Quit
@@ -68,23 +153,49 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Route regular messages (keys, etc.) only to the active tab
-	switch m.activeTab {
-	case 0:
-		// m.dashboard.Update returns (dashboardModel, tea.Cmd)
+	cmds = append(cmds, m.updateTab(m.activeTab, msg))
+
+	return m, tea.Batch(cmds...)
+}
+
+// nextTab is the tab after the active one, going back to the first
+func (m mainModel) nextTab() int {
+	for i, tab := range m.tabs {
+		if tab == m.activeTab {
+			return m.tabs[(i+1)%len(m.tabs)]
+		}
+	}
+	return m.tabs[0]
+}
+
+// updateTab passes a message to the model of a tab
+func (m *mainModel) updateTab(tab int, msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch tab {
+	case tabDashboard:
 		m.dashboard, cmd = m.dashboard.Update(msg)
-		cmds = append(cmds, cmd)
-	case 1:
-		// If your commitLog.Update returns (tea.Model, tea.Cmd)
+	case tabActivity:
+		m.activity, cmd = m.activity.Update(msg)
+	case tabCommits:
 		var newModel tea.Model
 		newModel, cmd = m.commitLog.Update(msg)
 		m.commitLog = newModel.(commitModel)
-		cmds = append(cmds, cmd)
-	case 2:
+	case tabFiles:
+		m.files, cmd = m.files.Update(msg)
+	case tabBranches:
+		m.branches, cmd = m.branches.Update(msg)
+	case tabReleases:
+		m.releases, cmd = m.releases.Update(msg)
+	case tabLogs:
 		m.logFinder, cmd = m.logFinder.Update(msg)
-		cmds = append(cmds, cmd)
+	case tabSessions:
+		m.sessions, cmd = m.sessions.Update(msg)
+	case tabHealth:
+		m.health, cmd = m.health.Update(msg)
+	case tabAccount:
+		m.account, cmd = m.account.Update(msg)
 	}
-
-	return m, tea.Batch(cmds...)
+	return cmd
 }
 
 func (m mainModel) View() string {
@@ -93,26 +204,39 @@ func (m mainModel) View() string {
 	}
 
 	// 1. Render Tabs
-	tabNames := []string{"Dashboard", "Commit History", "Logs"}
 	var tabs []string
-	for i, name := range tabNames {
+	for _, tab := range m.tabs {
 		style := lipgloss.NewStyle().Padding(0, 2)
-		if m.activeTab == i {
+		if m.activeTab == tab {
 			style = style.Background(lipgloss.Color("#5000ff")).Foreground(lipgloss.Color("#FFFFFF"))
 		}
-		tabs = append(tabs, style.Render(name))
+		tabs = append(tabs, style.Render(tabNames[tab]))
 	}
 	header := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
 
 	// 2. Get Sub-view Content
 	var content string
 	switch m.activeTab {
-	case 0:
+	case tabDashboard:
 		content = m.dashboard.View()
-	case 1:
+	case tabActivity:
+		content = m.activity.View()
+	case tabCommits:
 		content = m.commitLog.View()
-	case 2:
+	case tabFiles:
+		content = m.files.View()
+	case tabBranches:
+		content = m.branches.View()
+	case tabReleases:
+		content = m.releases.View()
+	case tabLogs:
 		content = m.logFinder.View()
+	case tabSessions:
+		content = m.sessions.View()
+	case tabHealth:
+		content = m.health.View()
+	case tabAccount:
+		content = m.account.View()
 	}
 
 	// 3. Join vertically and ensure no accidental wrapping
@@ -6,14 +6,18 @@ import (
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/go-git/go-git/v5"
 
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
+	gossh "golang.org/x/crypto/ssh"
 
 	"github.com/nim-sam/gitport/pkg/auth"
+	"github.com/nim-sam/gitport/pkg/events"
+	"github.com/nim-sam/gitport/pkg/logger"
+	"github.com/nim-sam/gitport/pkg/metrics"
+	"github.com/nim-sam/gitport/pkg/sessions"
 )
 
 /*
@@ -30,7 +34,8 @@ func Middleware(repoPath string) wish.Middleware {
 				return
 			}
 
-			// Check if user is admin
+			// Admins get the whole TUI, users with access to the repository a
+			// read-only browser of it
 			pubKey := sess.PublicKey()
 			if pubKey == nil {
 				wish.Errorln(sess, "Authentication required")
@@ -41,11 +46,14 @@ func Middleware(repoPath string) wish.Middleware {
 			userKey := pubKey.Type() + " " + base64.StdEncoding.EncodeToString(pubKey.Marshal())
 			user, exists := auth.GetUserByKey(userKey)
 
-			if !exists || user.Perm != "admin" {
-				wish.Errorln(sess, "Access denied: Admin permission required to access TUI")
+			if !exists || !canBrowse(user.Perm) {
+				wish.Errorln(sess, "Access denied: Read permission required to access TUI")
 				next(sess)
 				return
 			}
+			admin := user.Perm == "admin"
+
+			metrics.TUISessions.Inc()
 
 			// Open the git repository
 			repo, err := git.PlainOpen(repoPath)
@@ -55,89 +63,10 @@ func Middleware(repoPath string) wish.Middleware {
 				return
 			}
 
-			items, err := fetchCommits(repoPath, 30)
-			if err != nil {
-				wish.Errorln(sess, "Error fetching commits:", err)
-				next(sess)
-				return
-			}
-
-			// // Define fixed dimensions for the TUI
-			// defaultHeight := 16
-			// defaultWidth := 80
-
-			// l := list.New(items, commitDelegate{listFocused: true}, defaultWidth/2, defaultHeight)
-			// l.SetShowTitle(false)
-			// l.SetShowStatusBar(false)
-
-			// // Pre-initialize the viewport
-			// viewWidth := defaultWidth - (defaultWidth / 2) - 8
-			// vp := viewport.New(viewWidth, defaultHeight-2)
-
-			// // Populate the initial diff
-			// var initialHash string
-			// if len(items) > 0 {
-			// 	initialHash = items[0].(CommitItem).hash
-			// 	rawDiff := getCommitDiff(repo, initialHash)
-			// 	vp.SetContent(highlightDiff(rawDiff))
-			// }
-
-			// cm := commitModel{
-			// 	list:         l,
-			// 	viewport:     vp,
-			// 	repo:         repo,
-			// 	ready:        true,
-			// 	selectedHash: initialHash,
-			// }
-
-			// // Setup Log Finder
-			// logItems := []list.Item{
-			// 	LogItem{"ERROR", "DB Timeout", "2024-05-20 10:00"},
-			// 	LogItem{"INFO", "App Started", "2024-05-20 10:01"},
-			// 	LogItem{"WARN", "Disk Near Full", "2024-05-20 10:05"},
-			// }
-			// l_log := list.New(logItems, logDelegate{}, 80, 16)
-			// l_log.SetShowTitle(false)
-			// l_log.SetShowStatusBar(false)
-			// l_log.KeyMap.Quit.SetEnabled(false) // Don't let 'q' kill the whole app
-
-			// lf := logModel{
-			// 	list:  l_log,
-			// 	ready: true,
-			// }
-
-			// // Get PTY dimensions
-			// pty, _, active := sess.Pty()
-			// if !active {
-			// 	wish.Errorln(sess, "No PTY requested")
-			// 	next(sess)
-			// 	return
-			// }
-
-			// db := newDashboard()
-
-			// m := mainModel{
-			// 	activeTab: 0, // Start on Dashboard
-			// 	dashboard: db,
-			// 	commitLog: cm,
-			// 	logFinder: lf,
-			// 	width:     pty.Window.Width,
-			// 	height:    pty.Window.Height,
-			// }
-
-			// // Create a bubbletea program with the SSH session as input/output
-			// p := tea.NewProgram(
-			// 	m,
-			// 	tea.WithInput(sess),
-			// 	tea.WithOutput(sess),
-			// 	tea.WithAltScreen(),
-			// )
-
-			// if _, err := p.Run(); err != nil {
-			// 	wish.Errorln(sess, "Error running TUI:", err)
-			// }
-			//
-			//items, _ := fetchCommits(".", 30)
+			// Commits are loaded a page at a time as the list is scrolled
+			ref := defaultRef(repo)
+			source := newCommitSource(repo, repoPath, ref)
+			items := source.page(commitPageSize)
 
 			// 1. Define fixed dimensions for the inline view
 			// Since we are inline, pick a height that fits comfortably
@@ -170,60 +99,74 @@ func Middleware(repoPath string) wish.Middleware {
 			l_commit := list.New(items, commitDelegate{listFocused: true}, commitListWidth, commitAreaHeight)
 			l_commit.SetShowTitle(false)
 			l_commit.SetShowStatusBar(false)
+			// b and g open the ref picker and the graph rather than paging
+			l_commit.KeyMap.PrevPage.SetKeys("left", "h", "pgup", "u")
+			l_commit.KeyMap.GoToStart.SetKeys("home")
+			l_commit.KeyMap.GoToStart.SetHelp("home", "go to start")
 
 			// 2. Pre-initialize the viewport so 'ready' is true from the start
 			viewWidth := w - commitListWidth - 4
 			if viewWidth < 10 {
 				viewWidth = 10
 			}
-			vp := viewport.New(viewWidth, commitAreaHeight)
+			diff := newDiffView(viewWidth, commitAreaHeight)
 
 			// 3. Populate the initial diff so it's not empty
 			var initialHash string
 			if len(items) > 0 {
 				initialHash = items[0].(CommitItem).hash
-				rawDiff := getCommitDiff(repo, initialHash)
-				vp.SetContent(highlightDiff(rawDiff))
+				diff.setDiff(getCommitDiff(repoPath, initialHash))
 			}
 
 			cm := commitModel{
 
 				list: l_commit,
 
-				viewport:     vp,
+				diff:         diff,
 				repo:         repo,
 				ready:        true, // SET THIS TO TRUE
 				selectedHash: initialHash,
+				path:         repoPath,
+				source:       source,
+				total:        -1,
+				searchInput:  newSearchInput(),
+				canTag:       admin,
 			}
 
-			// Setup Log Finder
-			logItems := fetchLogItems()    // <--- Use the helper here
-			logHeight := contentHeight - 1 // Leave space for footer
-			if logHeight < 1 {
-				logHeight = 1
-			}
-			l_log := list.New(logItems, logDelegate{}, w, logHeight)
-			l_log.SetShowTitle(false)
-			l_log.SetShowStatusBar(false)
-			l_log.SetShowPagination(false)
-			l_log.KeyMap.Quit.SetEnabled(false) // Don't let 'q' kill the whole app
-
-			lf := logModel{
-				list:  l_log,
-				ready: true,
-			}
-
-			db := newDashboard()
-
 			m := mainModel{
-				activeTab: 0, // Start on Commit History to test
-				dashboard: db,
+				admin:     admin,
 				commitLog: cm,
-				logFinder: lf,
+				files:     newFiles(repo, repoPath, ref),
+				branches:  newBranches(repo, repoPath),
 				width:     w,
 				height:    h,
 			}
 
+			if admin {
+				// Setup Log Finder, following new entries for as long as the session lasts
+				records, unsubscribe := logger.Subscribe()
+				defer unsubscribe()
+
+				updates, stopUpdates := events.Subscribe()
+				defer stopUpdates()
+
+				ownID, _ := sessions.ID(sess.Context())
+
+				m.tabs = adminTabs
+				m.activeTab = tabDashboard
+				m.dashboard = newDashboard()
+				m.activity = newActivity(updates)
+				m.releases = newReleases(user.Name)
+				m.logFinder = newLogModel(w, contentHeight, records)
+				m.sessions = newSessions(user.Name, ownID)
+				m.health = newHealth()
+			} else {
+				m.tabs = userTabs
+				m.activeTab = tabCommits
+				m.releases = newReleases("")
+				m.account = newAccount(user.Name, user.Perm, pubKey.Type(), gossh.FingerprintSHA256(pubKey), sess.RemoteAddr().String())
+			}
+
 			p := tea.NewProgram(
 				m,
 				tea.WithInput(sess),  // Directs input from the SSH client
@@ -239,3 +182,14 @@ func Middleware(repoPath string) wish.Middleware {
 	}
 
 }
+
+// canBrowse reports whether a permission lets its user open the TUI: any
+// permission that may read the repository, as in Hook.AuthRepo
+func canBrowse(perm string) bool {
+	switch perm {
+	case "read", "auditor", "write", "admin":
+		return true
+	default:
+		return false
+	}
+}